@@ -1,17 +1,28 @@
 package main
 
 import (
+	"time"
+
 	"github.com/caarlos0/env/v11"
 	_ "github.com/joho/godotenv/autoload"
 )
 
 type config struct {
-	Listen             string `env:"LISTEN" envDefault:"localhost:9000"`
-	LogLevel           string `env:"LOG_LEVEL" envDefault:"debug"`
-	LogFmt             string `env:"LOG_FMT" envDefault:"console"`
-	CarApiBasePath     string `env:"CAR_BASE_PATH"`
-	MigrationDirectory string `env:"MIGRATION_DIR" envDefault:"file://init/migrations"`
-	DbAddr             string `env:"DB_HOST"`
+	Listen             string        `env:"LISTEN" envDefault:"localhost:9000"`
+	LogLevel           string        `env:"LOG_LEVEL" envDefault:"debug"`
+	LogFmt             string        `env:"LOG_FMT" envDefault:"console"`
+	CarApiBasePath     string        `env:"CAR_BASE_PATH"`
+	MigrationDirectory string        `env:"MIGRATION_DIR" envDefault:"file://init/migrations"`
+	DbAddr             string        `env:"DB_HOST"`
+	RequireIfMatch     bool          `env:"REQUIRE_IF_MATCH" envDefault:"false"`
+	CacheSize          int           `env:"CACHE_SIZE" envDefault:"0"`
+	CacheTTL           time.Duration `env:"CACHE_TTL" envDefault:"30s"`
+	BatchDeleteMax     int           `env:"BATCH_DELETE_MAX" envDefault:"1000"`
+	PurgeRetention     time.Duration `env:"PURGE_RETENTION" envDefault:"720h"`
+	PurgeInterval      time.Duration `env:"PURGE_INTERVAL" envDefault:"1h"`
+	ImportBatchSize    int           `env:"IMPORT_BATCH_SIZE" envDefault:"1000"`
+	PlateFormatsFile   string        `env:"PLATE_FORMATS_FILE"`
+	VINCheckMark       bool          `env:"VIN_CHECK_MARK" envDefault:"false"`
 }
 
 func initConfig() (*config, error) {
@@ -10,7 +10,10 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/mi-raf/cars-catalog/internal"
 	"github.com/mi-raf/cars-catalog/internal/api"
+	"github.com/mi-raf/cars-catalog/internal/plate"
+	"github.com/mi-raf/cars-catalog/internal/service"
 	"github.com/mi-raf/cars-catalog/internal/swagger"
+	"github.com/mi-raf/cars-catalog/internal/vin"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/xlab/closer"
@@ -20,6 +23,15 @@ import (
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 )
 
+type app struct {
+	api   *api.API
+	purge *service.PurgeJob
+}
+
+func newApp(a *api.API, p *service.PurgeJob) *app {
+	return &app{api: a, purge: p}
+}
+
 // @title           Car API
 // @version         1.0
 // @description     This is a sample car's server.
@@ -58,11 +70,12 @@ func main() {
 	}
 	closer.Bind(cleanup)
 	closer.Bind(func() {
-		if err := a.Close(); err != nil {
+		if err := a.api.Close(); err != nil {
 			log.Error().Err(err).Msg("Can't stop web application")
 		}
 	})
-	if err := a.Start(); err != nil {
+	go a.purge.Run(ctx)
+	if err := a.api.Start(); err != nil {
 		log.Fatal().Err(err).Msg("Can't start app")
 	}
 
@@ -94,12 +107,28 @@ func initHttpClientConfiguration(cfg *config) *swagger.Configuration {
 	}
 }
 
-func initValidator() *validator.Validate {
+func initValidator(plates *plate.Registry) *validator.Validate {
 	validate := validator.New(validator.WithRequiredStructEnabled())
 	validate.RegisterValidation("c-year", internal.LessThanCurrYearValidator)
+	validate.RegisterValidation("plate", internal.PlateValidator(plates))
+	validate.RegisterValidation("vin", internal.VINValidator)
 	return validate
 }
 
+// initPlateRegistry knows the Russian formats and those from PLATE_FORMATS_FILE.
+func initPlateRegistry(cfg *config) (*plate.Registry, error) {
+	formats := plate.RussianFormats()
+	if len(cfg.PlateFormatsFile) > 0 {
+		extra, err := plate.LoadFormats(cfg.PlateFormatsFile)
+		if err != nil {
+			return nil, err
+		}
+		formats = append(formats, extra...)
+	}
+	log.Debug().Int("formats", len(formats)).Str("regions version", plate.RegionsVersion()).Msg("create plate registry")
+	return plate.NewRegistry(formats...)
+}
+
 func migrateData(cfg *config) (func(), error) {
 	log.Debug().Msg("start migrating data")
 	m, err := migrate.New(
@@ -152,5 +181,26 @@ func initPostgresConnection(ctx context.Context, cfg *config) (*pgxpool.Pool, fu
 }
 
 func initApiConfig(cfg *config) *api.Config {
-	return &api.Config{Addr: cfg.Listen}
+	return &api.Config{Addr: cfg.Listen, RequireIfMatch: cfg.RequireIfMatch}
+}
+
+func initCarListCache(cfg *config) *service.CarListCache {
+	return service.NewCarListCache(cfg.CacheSize, cfg.CacheTTL)
+}
+
+func initBatchDeleteConfig(cfg *config) *service.BatchDeleteConfig {
+	return &service.BatchDeleteConfig{MaxMatches: cfg.BatchDeleteMax}
+}
+
+func initPurgeConfig(cfg *config) *service.PurgeConfig {
+	return &service.PurgeConfig{Retention: cfg.PurgeRetention, Interval: cfg.PurgeInterval}
+}
+
+func initImportConfig(cfg *config) *service.ImportConfig {
+	return &service.ImportConfig{BatchSize: cfg.ImportBatchSize}
+}
+
+func initVINConfig(cfg *config) *service.VINConfig {
+	log.Debug().Bool("check mark", cfg.VINCheckMark).Str("wmi version", vin.WMIVersion()).Msg("vin config")
+	return &service.VINConfig{CheckMark: cfg.VINCheckMark}
 }
@@ -13,17 +13,26 @@ import (
 	"github.com/mi-raf/cars-catalog/internal/swagger"
 )
 
-func initApp(ctx context.Context, cfg *config) (a *api.API, closer func(), err error) {
+func initApp(ctx context.Context, cfg *config) (a *app, closer func(), err error) {
 	wire.Build(
 		initApiConfig,
 		initPostgresConnection,
+		initPlateRegistry,
 		initValidator,
 		initHttpClientConfiguration,
+		initCarListCache,
+		initImportConfig,
+		initVINConfig,
+		initBatchDeleteConfig,
 		database.NewCarRepository,
 		wire.Bind(new(database.CarRepository), new(*database.PgCarRepository)),
 		swagger.NewAPIClient,
+		wire.Struct(new(service.Options), "*"),
 		service.NewCarService,
 		api.New,
+		initPurgeConfig,
+		service.NewPurgeJob,
+		newApp,
 	)
 	return nil, nil, nil
 }
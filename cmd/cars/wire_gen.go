@@ -22,7 +22,7 @@ import (
 
 // Injectors from wire.go:
 
-func initApp(ctx context.Context, cfg *config) (*api.API, func(), error) {
+func initApp(ctx context.Context, cfg *config) (*app, func(), error) {
 	apiConfig := initApiConfig(cfg)
 	pool, cleanup, err := initPostgresConnection(ctx, cfg)
 	if err != nil {
@@ -35,14 +35,33 @@ func initApp(ctx context.Context, cfg *config) (*api.API, func(), error) {
 	}
 	configuration := initHttpClientConfiguration(cfg)
 	apiClient := swagger.NewAPIClient(configuration)
-	validate := initValidator()
-	carServise := service.NewCarService(pgCarRepository, apiClient, validate)
+	registry, err := initPlateRegistry(cfg)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	validate := initValidator(registry)
+	lru := initCarListCache(cfg)
+	importConfig := initImportConfig(cfg)
+	vinConfig := initVINConfig(cfg)
+	batchDeleteConfig := initBatchDeleteConfig(cfg)
+	options := service.Options{
+		Cache:       lru,
+		Import:      importConfig,
+		Plates:      registry,
+		VIN:         vinConfig,
+		BatchDelete: batchDeleteConfig,
+	}
+	carServise := service.NewCarService(pgCarRepository, apiClient, validate, options)
 	apiAPI, err := api.New(ctx, apiConfig, carServise)
 	if err != nil {
 		cleanup()
 		return nil, nil, err
 	}
-	return apiAPI, func() {
+	purgeConfig := initPurgeConfig(cfg)
+	purgeJob := service.NewPurgeJob(carServise, purgeConfig)
+	mainApp := newApp(apiAPI, purgeJob)
+	return mainApp, func() {
 		cleanup()
 	}, nil
 }
@@ -15,15 +15,60 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/car": {
+        "/audit": {
             "get": {
-                "description": "method to get some cars from database with filter and pagination. If filter is empty this method return all cars.",
-                "consumes": [
-                    "*/*"
-                ],
+                "description": "method to get changes of all cars and owners made since the given time. The actor of a change is the X-Actor header of its request taken as sent, it is not authenticated by the service, so it can only be trusted when a proxy in front of the service sets the header.",
                 "produces": [
                     "application/json"
                 ],
+                "summary": "Get audit log.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "RFC 3339 time",
+                        "name": "since",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "limit of responce size",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/api.AuditRecordJSON"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/car": {
+            "get": {
+                "description": "method to get some cars from database with filter and pagination. If filter is empty this method return all cars. With Accept: text/csv or format=csv all matching cars are streamed as CSV ignoring pagination.",
+                "produces": [
+                    "application/json",
+                    "text/csv"
+                ],
                 "summary": "Get cars with filter",
                 "parameters": [
                     {
@@ -79,6 +124,160 @@ const docTemplate = `{
                         "description": "car's filter param owner's patronymic",
                         "name": "patronymic",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "car's filter param region code of the plate",
+                        "name": "region",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "car's filter param VIN",
+                        "name": "vin",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "car's filter param color, case insensitive",
+                        "name": "color",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "sedan",
+                            "hatchback",
+                            "wagon",
+                            "coupe",
+                            "convertible",
+                            "suv",
+                            "crossover",
+                            "minivan",
+                            "pickup",
+                            "van",
+                            "bus",
+                            "truck",
+                            "motorcycle"
+                        ],
+                        "type": "string",
+                        "description": "car's filter param body type",
+                        "name": "body_type",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "petrol",
+                            "diesel",
+                            "lpg",
+                            "cng",
+                            "hybrid",
+                            "electric"
+                        ],
+                        "type": "string",
+                        "description": "car's filter param fuel",
+                        "name": "fuel",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "manual",
+                            "automatic",
+                            "robot",
+                            "cvt"
+                        ],
+                        "type": "string",
+                        "description": "car's filter param transmission",
+                        "name": "transmission",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "car's filter param least mileage in km",
+                        "name": "mileage_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "car's filter param greatest mileage in km",
+                        "name": "mileage_max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "car's filter param least engine volume in cm3",
+                        "name": "engine_volume_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "car's filter param greatest engine volume in cm3",
+                        "name": "engine_volume_max",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "active",
+                            "sold",
+                            "stolen",
+                            "scrapped",
+                            "exported"
+                        ],
+                        "type": "string",
+                        "description": "car's filter param lifecycle status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "comma separated sort keys, prefix a key with - to sort descending: reg_num, mark, model, year, color, body_type, fuel, engine_volume, transmission, mileage",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "include soft deleted cars",
+                        "name": "include_deleted",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC 3339 time to get the catalog as it was at that instant",
+                        "name": "as_of",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv"
+                        ],
+                        "type": "string",
+                        "description": "csv to stream all matching cars as CSV instead of a page of JSON",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "prepend UTF-8 BOM to the CSV for Excel",
+                        "name": "bom",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "comma separated extra data of the cars: last_service adds the date of the latest maintenance",
+                        "name": "expand",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "comma separated facets counted for the filter without the facet's own condition: mark, model, year. The response becomes CarsWithFacetsJSON",
+                        "name": "facets",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of a previously received listing",
+                        "name": "If-None-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -86,8 +285,17 @@ const docTemplate = `{
                         "description": "OK",
                         "schema": {
                             "$ref": "#/definitions/api.CarJSON"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "listing's entity tag"
+                            }
                         }
                     },
+                    "304": {
+                        "description": "Not Modified"
+                    },
                     "400": {
                         "description": "error",
                         "schema": {
@@ -103,6 +311,7 @@ const docTemplate = `{
                 }
             },
             "post": {
+                "description": "method to add cars known to the car API. When VIN checks are enabled and the mark of an added car does not match the manufacturer of its VIN, the cars are added and the mismatches are returned.",
                 "consumes": [
                     "application/json"
                 ],
@@ -123,7 +332,10 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "201": {
-                        "description": "Created"
+                        "description": "only when there are VIN mismatches",
+                        "schema": {
+                            "$ref": "#/definitions/api.AddCarsResponseJSON"
+                        }
                     },
                     "400": {
                         "description": "error",
@@ -131,6 +343,12 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "409": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
                         "description": "error",
                         "schema": {
@@ -140,25 +358,31 @@ const docTemplate = `{
                 }
             },
             "delete": {
+                "description": "method to delete cars by a list of registration numbers or by a filter in one transaction. A filter must not be empty and must not match more cars than the configured maximum, otherwise nothing is deleted. Cars are soft deleted and can be restored until they are purged. With dryRun the cars that would be deleted are reported and nothing is changed.",
                 "consumes": [
-                    "*/*"
+                    "application/json"
                 ],
                 "produces": [
                     "application/json"
                 ],
-                "summary": "Delete car by registration namber.",
+                "summary": "Delete several cars.",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "car's param registration number",
-                        "name": "reg_num",
-                        "in": "path",
-                        "required": true
+                        "description": "registration numbers or filter",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.BatchDeleteRequestJSON"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.BatchDeleteResponseJSON"
+                        }
                     },
                     "400": {
                         "description": "error",
@@ -175,6 +399,7 @@ const docTemplate = `{
                 }
             },
             "patch": {
+                "description": "mileage is logged as an odometer reading taken today; a mileage lower than the last reading is refused with 409, use POST /car/{regnum}/mileage with override to log a rollback.",
                 "consumes": [
                     "application/json"
                 ],
@@ -191,6 +416,12 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/api.CarJSON"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "car's ETag from GET /car/{regnum}",
+                        "name": "If-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -203,6 +434,30 @@ const docTemplate = `{
                             "type": "string"
                         }
                     },
+                    "404": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "412": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "428": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "500": {
                         "description": "error",
                         "schema": {
@@ -212,67 +467,2387 @@ const docTemplate = `{
                 }
             }
         },
-        "/health": {
+        "/car/export": {
             "get": {
-                "description": "get the status of server.",
-                "consumes": [
-                    "*/*"
-                ],
+                "description": "method to stream all cars matching the filter, one JSON object per line, without pagination. The stream is aborted when the client disconnects.",
                 "produces": [
-                    "application/json"
+                    "application/x-ndjson"
                 ],
-                "summary": "Show the status of server.",
-                "responses": {
-                    "200": {
-                        "description": "OK"
-                    }
-                }
-            }
-        }
-    },
-    "definitions": {
-        "api.CarJSON": {
-            "type": "object",
-            "properties": {
-                "mark": {
-                    "type": "string"
-                },
-                "model": {
-                    "type": "string"
-                },
-                "owner": {
-                    "$ref": "#/definitions/api.PeopleJSON"
-                },
-                "regNum": {
-                    "type": "string"
-                },
-                "year": {
-                    "type": "integer"
-                }
-            }
-        },
-        "api.PeopleJSON": {
-            "type": "object",
-            "properties": {
-                "name": {
-                    "type": "string"
-                },
-                "patronymic": {
-                    "type": "string"
-                },
-                "surname": {
-                    "type": "string"
-                }
-            }
-        },
-        "api.RegNumRequestJSON": {
-            "type": "object",
-            "properties": {
-                "regNums": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
+                "summary": "Export cars as NDJSON.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "car's filter param year",
+                        "name": "year",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "car's filter param registration number",
+                        "name": "reg_num",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "car's filter param mark",
+                        "name": "mark",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "car's filter param model",
+                        "name": "model",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "car's filter param owner's name",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "car's filter param owner's surname",
+                        "name": "surname",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "car's filter param owner's patronymic",
+                        "name": "patronymic",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "car's filter param region code of the plate",
+                        "name": "region",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "car's filter param VIN",
+                        "name": "vin",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "car's filter param color, case insensitive",
+                        "name": "color",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "sedan",
+                            "hatchback",
+                            "wagon",
+                            "coupe",
+                            "convertible",
+                            "suv",
+                            "crossover",
+                            "minivan",
+                            "pickup",
+                            "van",
+                            "bus",
+                            "truck",
+                            "motorcycle"
+                        ],
+                        "type": "string",
+                        "description": "car's filter param body type",
+                        "name": "body_type",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "petrol",
+                            "diesel",
+                            "lpg",
+                            "cng",
+                            "hybrid",
+                            "electric"
+                        ],
+                        "type": "string",
+                        "description": "car's filter param fuel",
+                        "name": "fuel",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "manual",
+                            "automatic",
+                            "robot",
+                            "cvt"
+                        ],
+                        "type": "string",
+                        "description": "car's filter param transmission",
+                        "name": "transmission",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "car's filter param least mileage in km",
+                        "name": "mileage_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "car's filter param greatest mileage in km",
+                        "name": "mileage_max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "car's filter param least engine volume in cm3",
+                        "name": "engine_volume_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "car's filter param greatest engine volume in cm3",
+                        "name": "engine_volume_max",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "active",
+                            "sold",
+                            "stolen",
+                            "scrapped",
+                            "exported"
+                        ],
+                        "type": "string",
+                        "description": "car's filter param lifecycle status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "include soft deleted cars",
+                        "name": "include_deleted",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC 3339 time to get the catalog as it was at that instant",
+                        "name": "as_of",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.CarJSON"
+                        }
+                    },
+                    "400": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/car/import": {
+            "post": {
+                "description": "method to add manually curated cars from CSV (same columns as the CSV export, header required) or NDJSON (one car object per line). Every row is validated and invalid rows are reported by line number; valid rows are committed in batches. When a batch can not be saved the batches before it stay committed, the import stops and the report with the failed line range is returned with status 500. Cars that already exist or whose VIN belongs to another car are skipped. With dry_run nothing is changed.",
+                "consumes": [
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Import cars from a file.",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "validate and report without saving",
+                        "name": "dry_run",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.ImportReportJSON"
+                        }
+                    },
+                    "400": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "413": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "415": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "report of the batches committed before the failed one",
+                        "schema": {
+                            "$ref": "#/definitions/api.ImportReportJSON"
+                        }
+                    }
+                }
+            }
+        },
+        "/car/search": {
+            "get": {
+                "description": "method to find active cars by words of registration number, mark, model and owner's names. Words match as prefixes and misspellings are tolerated; the most relevant cars come first.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Search cars by free text.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "search text",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "limit of responce size",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "offset of responce for database",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/api.SearchHitJSON"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/car/stats": {
+            "get": {
+                "description": "method to count cars matching the filter grouped by mark, model, year bucket, owner or plate region. Only the top groups by count are returned; total counts all matching cars.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Car statistics.",
+                "parameters": [
+                    {
+                        "enum": [
+                            "mark",
+                            "model",
+                            "year",
+                            "owner",
+                            "region"
+                        ],
+                        "type": "string",
+                        "description": "dimension to group by",
+                        "name": "group_by",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "number of the largest groups to return",
+                        "name": "top",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "width of a year group in years",
+                        "name": "year_bucket",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "car's filter param year",
+                        "name": "year",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "car's filter param registration number",
+                        "name": "reg_num",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "car's filter param mark",
+                        "name": "mark",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "car's filter param model",
+                        "name": "model",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "car's filter param owner's name",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "car's filter param owner's surname",
+                        "name": "surname",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "car's filter param owner's patronymic",
+                        "name": "patronymic",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "car's filter param region code of the plate",
+                        "name": "region",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "car's filter param VIN",
+                        "name": "vin",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "car's filter param color, case insensitive",
+                        "name": "color",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "sedan",
+                            "hatchback",
+                            "wagon",
+                            "coupe",
+                            "convertible",
+                            "suv",
+                            "crossover",
+                            "minivan",
+                            "pickup",
+                            "van",
+                            "bus",
+                            "truck",
+                            "motorcycle"
+                        ],
+                        "type": "string",
+                        "description": "car's filter param body type",
+                        "name": "body_type",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "petrol",
+                            "diesel",
+                            "lpg",
+                            "cng",
+                            "hybrid",
+                            "electric"
+                        ],
+                        "type": "string",
+                        "description": "car's filter param fuel",
+                        "name": "fuel",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "manual",
+                            "automatic",
+                            "robot",
+                            "cvt"
+                        ],
+                        "type": "string",
+                        "description": "car's filter param transmission",
+                        "name": "transmission",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "car's filter param least mileage in km",
+                        "name": "mileage_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "car's filter param greatest mileage in km",
+                        "name": "mileage_max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "car's filter param least engine volume in cm3",
+                        "name": "engine_volume_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "car's filter param greatest engine volume in cm3",
+                        "name": "engine_volume_max",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "active",
+                            "sold",
+                            "stolen",
+                            "scrapped",
+                            "exported"
+                        ],
+                        "type": "string",
+                        "description": "car's filter param lifecycle status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "include soft deleted cars",
+                        "name": "include_deleted",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC 3339 time to get the catalog as it was at that instant",
+                        "name": "as_of",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.StatsJSON"
+                        }
+                    },
+                    "400": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/car/{regnum}": {
+            "get": {
+                "description": "method to get one car. The car's version is returned in the ETag header and can be sent back in If-Match on update or delete. A plate the car had before it was replated is redirected to the car.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get car by registration number",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "car's registration number",
+                        "name": "regnum",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC 3339 time to get the car as it was at that instant",
+                        "name": "as_of",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.CarJSON"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "car's version"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "consumes": [
+                    "*/*"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Delete car by registration namber.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "car's param registration number",
+                        "name": "regnum",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "car's ETag from GET /car/{regnum}",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "412": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "428": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/car/{regnum}/history": {
+            "get": {
+                "description": "method to get every recorded change of the car with its state before and after the change. The actor of a change is the X-Actor header of its request taken as sent, it is not authenticated by the service.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get change history of a car.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "car's registration number",
+                        "name": "regnum",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/api.AuditRecordJSON"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/car/{regnum}/maintenance": {
+            "get": {
+                "description": "method to get the maintenance of the car, oldest first.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get maintenance records of a car.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "car's registration number",
+                        "name": "regnum",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "least date of the records as YYYY-MM-DD",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "greatest date of the records as YYYY-MM-DD",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "service",
+                            "oil_change",
+                            "inspection",
+                            "repair",
+                            "tires",
+                            "brakes",
+                            "battery",
+                            "bodywork",
+                            "other"
+                        ],
+                        "type": "string",
+                        "description": "type of work",
+                        "name": "work_type",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/api.MaintenanceJSON"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "method to attach a service or maintenance event to the car.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Add a maintenance record.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "car's registration number",
+                        "name": "regnum",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "maintenance event",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.MaintenanceRequestJSON"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/api.MaintenanceJSON"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "record's URL"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/car/{regnum}/maintenance/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get a maintenance record.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "car's registration number",
+                        "name": "regnum",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "record's id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.MaintenanceJSON"
+                        }
+                    },
+                    "400": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Replace a maintenance record.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "car's registration number",
+                        "name": "regnum",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "record's id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "maintenance event",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.MaintenanceRequestJSON"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.MaintenanceJSON"
+                        }
+                    },
+                    "400": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "summary": "Delete a maintenance record.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "car's registration number",
+                        "name": "regnum",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "record's id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/car/{regnum}/mileage": {
+            "get": {
+                "description": "method to get the mileage log of the car, oldest reading first.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get odometer readings of a car.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "car's registration number",
+                        "name": "regnum",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/api.MileageReadingJSON"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "method to add a mileage reading of the car. Readings must not decrease by date; a reading out of order is refused with 409 unless override is set, then it is logged as a rollback and the car gets suspiciousRollback.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Log an odometer reading.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "car's registration number",
+                        "name": "regnum",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "odometer reading",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.MileageRequestJSON"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created"
+                    },
+                    "400": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/car/{regnum}/owners": {
+            "get": {
+                "description": "method to get the full chain of the car's owners, oldest first. The current owner has no validTo.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get owners of a car.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "car's registration number",
+                        "name": "regnum",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/api.OwnershipJSON"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/car/{regnum}/plates": {
+            "get": {
+                "description": "method to get every plate change of the car, oldest first.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Get plate history of a car.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "car's registration number, a former one is accepted",
+                        "name": "regnum",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/api.PlateChangeJSON"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/car/{regnum}/replate": {
+            "post": {
+                "description": "method to change the car's registration number keeping its id, owners and history. Afterwards a lookup of the old plate is redirected to the car and the car's sub-resources can be read by the old plate; writes need the current plate and give 404 for the old one.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Give a car new plates.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "car's registration number",
+                        "name": "regnum",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "new registration number",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.ReplateRequestJSON"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "car's ETag from GET /car/{regnum}",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "car's new URL"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "412": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "428": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/car/{regnum}/restore": {
+            "post": {
+                "consumes": [
+                    "*/*"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Restore soft deleted car.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "car's registration number",
+                        "name": "regnum",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "404": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/car/{regnum}/status": {
+            "post": {
+                "description": "method to move the car to another status with a reason. Allowed transitions: active to sold, stolen, scrapped or exported; sold to active, stolen, scrapped or exported; stolen to active or scrapped; exported to active. Scrapped is terminal. Scrapped and exported cars can not be updated, transferred, re-plated or given odometer readings; stolen cars can not be transferred or re-plated. Such writes are refused with 409.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Change lifecycle status of a car.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "car's registration number",
+                        "name": "regnum",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "new status and its reason",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.StatusRequestJSON"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "car's ETag from GET /car/{regnum}",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "412": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "428": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/car/{regnum}/transfer": {
+            "post": {
+                "description": "method to close the current ownership of the car and open a new one atomically.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Transfer car to a new owner.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "car's registration number",
+                        "name": "regnum",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "new owner",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.TransferRequestJSON"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "car's ETag from GET /car/{regnum}",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "412": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "428": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/health": {
+            "get": {
+                "description": "get the status of server.",
+                "consumes": [
+                    "*/*"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Show the status of server.",
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/reports/catalog.xlsx": {
+            "get": {
+                "description": "method to build an XLSX workbook with the cars matching the filter, their owners and a summary by mark and year.",
+                "produces": [
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                ],
+                "summary": "Catalog report as Excel workbook.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "car's filter param year",
+                        "name": "year",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "car's filter param registration number",
+                        "name": "reg_num",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "car's filter param mark",
+                        "name": "mark",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "car's filter param model",
+                        "name": "model",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "car's filter param owner's name",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "car's filter param owner's surname",
+                        "name": "surname",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "car's filter param owner's patronymic",
+                        "name": "patronymic",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "car's filter param region code of the plate",
+                        "name": "region",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "car's filter param VIN",
+                        "name": "vin",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "car's filter param color, case insensitive",
+                        "name": "color",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "sedan",
+                            "hatchback",
+                            "wagon",
+                            "coupe",
+                            "convertible",
+                            "suv",
+                            "crossover",
+                            "minivan",
+                            "pickup",
+                            "van",
+                            "bus",
+                            "truck",
+                            "motorcycle"
+                        ],
+                        "type": "string",
+                        "description": "car's filter param body type",
+                        "name": "body_type",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "petrol",
+                            "diesel",
+                            "lpg",
+                            "cng",
+                            "hybrid",
+                            "electric"
+                        ],
+                        "type": "string",
+                        "description": "car's filter param fuel",
+                        "name": "fuel",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "manual",
+                            "automatic",
+                            "robot",
+                            "cvt"
+                        ],
+                        "type": "string",
+                        "description": "car's filter param transmission",
+                        "name": "transmission",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "car's filter param least mileage in km",
+                        "name": "mileage_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "car's filter param greatest mileage in km",
+                        "name": "mileage_max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "car's filter param least engine volume in cm3",
+                        "name": "engine_volume_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "car's filter param greatest engine volume in cm3",
+                        "name": "engine_volume_max",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "active",
+                            "sold",
+                            "stolen",
+                            "scrapped",
+                            "exported"
+                        ],
+                        "type": "string",
+                        "description": "car's filter param lifecycle status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "include soft deleted cars",
+                        "name": "include_deleted",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC 3339 time to get the catalog as it was at that instant",
+                        "name": "as_of",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/suggest/mark": {
+            "get": {
+                "description": "method to autocomplete marks by case insensitive prefix, the most common first.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Suggest car marks.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "prefix of the mark",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "max number of suggestions",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/api.SuggestionJSON"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/suggest/model": {
+            "get": {
+                "description": "method to autocomplete models by case insensitive prefix, optionally of one mark, the most common first.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Suggest car models.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "prefix of the model",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "mark the models belong to",
+                        "name": "mark",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "max number of suggestions",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/api.SuggestionJSON"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/suggest/owner": {
+            "get": {
+                "description": "method to autocomplete owners by case insensitive prefix of \"surname name\" or \"name surname\", owners of more cars first.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Suggest owners.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "prefix of the owner's full name",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "max number of suggestions",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/api.SuggestionJSON"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/vin/{vin}/decode": {
+            "get": {
+                "description": "method to tell the manufacturer and model year of a VIN offline, from the embedded WMI table. Mark and country are omitted for unknown manufacturers.",
+                "produces": [
+                    "application/json"
+                ],
+                "summary": "Decode VIN.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "vehicle identification number",
+                        "name": "vin",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.VINInfoJSON"
+                        }
+                    },
+                    "400": {
+                        "description": "error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "api.AddCarsResponseJSON": {
+            "type": "object",
+            "properties": {
+                "vinMismatches": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.VINMismatchJSON"
+                    }
+                }
+            }
+        },
+        "api.AuditRecordJSON": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "actor": {
+                    "type": "string"
+                },
+                "after": {
+                    "type": "object"
+                },
+                "before": {
+                    "type": "object"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "entity": {
+                    "type": "string"
+                },
+                "entityId": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "requestId": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.BatchDeleteRequestJSON": {
+            "type": "object",
+            "properties": {
+                "dryRun": {
+                    "type": "boolean"
+                },
+                "filter": {
+                    "$ref": "#/definitions/api.CarFilterJSON"
+                },
+                "regNums": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "api.BatchDeleteResponseJSON": {
+            "type": "object",
+            "properties": {
+                "deleted": {
+                    "type": "integer"
+                },
+                "dryRun": {
+                    "type": "boolean"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.DeleteResultJSON"
+                    }
+                }
+            }
+        },
+        "api.CarFilterJSON": {
+            "type": "object",
+            "properties": {
+                "bodyType": {
+                    "type": "string"
+                },
+                "color": {
+                    "type": "string"
+                },
+                "engineVolumeMax": {
+                    "type": "integer"
+                },
+                "engineVolumeMin": {
+                    "type": "integer"
+                },
+                "fuel": {
+                    "type": "string"
+                },
+                "includeDeleted": {
+                    "type": "boolean"
+                },
+                "mark": {
+                    "type": "string"
+                },
+                "mileageMax": {
+                    "type": "integer"
+                },
+                "mileageMin": {
+                    "type": "integer"
+                },
+                "model": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "patronymic": {
+                    "type": "string"
+                },
+                "regNum": {
+                    "type": "string"
+                },
+                "region": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "surname": {
+                    "type": "string"
+                },
+                "transmission": {
+                    "type": "string"
+                },
+                "vin": {
+                    "type": "string"
+                },
+                "year": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.CarJSON": {
+            "type": "object",
+            "properties": {
+                "bodyType": {
+                    "type": "string",
+                    "enum": [
+                        "sedan",
+                        "hatchback",
+                        "wagon",
+                        "coupe",
+                        "convertible",
+                        "suv",
+                        "crossover",
+                        "minivan",
+                        "pickup",
+                        "van",
+                        "bus",
+                        "truck",
+                        "motorcycle"
+                    ]
+                },
+                "color": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "type": "string"
+                },
+                "engineVolume": {
+                    "description": "EngineVolume is in cm3",
+                    "type": "integer"
+                },
+                "fuel": {
+                    "type": "string",
+                    "enum": [
+                        "petrol",
+                        "diesel",
+                        "lpg",
+                        "cng",
+                        "hybrid",
+                        "electric"
+                    ]
+                },
+                "id": {
+                    "description": "Id is assigned by the catalog and ignored on input",
+                    "type": "integer"
+                },
+                "lastServiceOn": {
+                    "description": "LastServiceOn is only present with expand=last_service",
+                    "type": "string"
+                },
+                "mark": {
+                    "type": "string"
+                },
+                "mileage": {
+                    "description": "Mileage is in km",
+                    "type": "integer"
+                },
+                "model": {
+                    "type": "string"
+                },
+                "owner": {
+                    "$ref": "#/definitions/api.PeopleJSON"
+                },
+                "plateType": {
+                    "description": "plate type and region are detected from the plate, they are ignored on input",
+                    "type": "string",
+                    "enum": [
+                        "civil",
+                        "taxi",
+                        "trailer",
+                        "moto",
+                        "police",
+                        "diplomatic"
+                    ]
+                },
+                "regNum": {
+                    "type": "string"
+                },
+                "regionCode": {
+                    "type": "string"
+                },
+                "regionName": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "active",
+                        "sold",
+                        "stolen",
+                        "scrapped",
+                        "exported"
+                    ]
+                },
+                "statusReason": {
+                    "type": "string"
+                },
+                "suspiciousRollback": {
+                    "description": "SuspiciousRollback is set once a lower odometer reading was logged",
+                    "type": "boolean"
+                },
+                "transmission": {
+                    "type": "string",
+                    "enum": [
+                        "manual",
+                        "automatic",
+                        "robot",
+                        "cvt"
+                    ]
+                },
+                "vin": {
+                    "type": "string"
+                },
+                "year": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.DeleteResultJSON": {
+            "type": "object",
+            "properties": {
+                "regNum": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "deleted",
+                        "not_found"
+                    ]
+                }
+            }
+        },
+        "api.ImportErrorJSON": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "line": {
+                    "type": "integer"
+                },
+                "regNum": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.ImportFailureJSON": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "fromLine": {
+                    "type": "integer"
+                },
+                "toLine": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.ImportReportJSON": {
+            "type": "object",
+            "properties": {
+                "dryRun": {
+                    "type": "boolean"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.ImportErrorJSON"
+                    }
+                },
+                "failed": {
+                    "description": "Failed is only present when a batch could not be saved",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/api.ImportFailureJSON"
+                        }
+                    ]
+                },
+                "imported": {
+                    "type": "integer"
+                },
+                "skipped": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "valid": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.MaintenanceJSON": {
+            "type": "object",
+            "properties": {
+                "cost": {
+                    "type": "number"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "doneOn": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "mileage": {
+                    "type": "integer"
+                },
+                "notes": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "workType": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.MaintenanceRequestJSON": {
+            "type": "object",
+            "properties": {
+                "cost": {
+                    "description": "Cost is in roubles, kopecks are kept",
+                    "type": "number"
+                },
+                "doneOn": {
+                    "description": "DoneOn is the date of the event as YYYY-MM-DD",
+                    "type": "string"
+                },
+                "mileage": {
+                    "description": "Mileage is in km",
+                    "type": "integer"
+                },
+                "notes": {
+                    "type": "string"
+                },
+                "workType": {
+                    "type": "string",
+                    "enum": [
+                        "service",
+                        "oil_change",
+                        "inspection",
+                        "repair",
+                        "tires",
+                        "brakes",
+                        "battery",
+                        "bodywork",
+                        "other"
+                    ]
+                }
+            }
+        },
+        "api.MileageReadingJSON": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "mileage": {
+                    "type": "integer"
+                },
+                "readOn": {
+                    "type": "string"
+                },
+                "rollback": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "api.MileageRequestJSON": {
+            "type": "object",
+            "properties": {
+                "mileage": {
+                    "description": "Mileage is in km",
+                    "type": "integer"
+                },
+                "override": {
+                    "description": "Override logs a reading lower than an earlier one and marks the car",
+                    "type": "boolean"
+                },
+                "readOn": {
+                    "description": "ReadOn is the date of the reading as YYYY-MM-DD, today when empty",
+                    "type": "string"
+                }
+            }
+        },
+        "api.OwnershipJSON": {
+            "type": "object",
+            "properties": {
+                "owner": {
+                    "$ref": "#/definitions/api.PeopleJSON"
+                },
+                "validFrom": {
+                    "type": "string"
+                },
+                "validTo": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.PeopleJSON": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "patronymic": {
+                    "type": "string"
+                },
+                "surname": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.PlateChangeJSON": {
+            "type": "object",
+            "properties": {
+                "changedAt": {
+                    "type": "string"
+                },
+                "newRegNum": {
+                    "type": "string"
+                },
+                "oldRegNum": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.RegNumRequestJSON": {
+            "type": "object",
+            "properties": {
+                "regNums": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "api.ReplateRequestJSON": {
+            "type": "object",
+            "properties": {
+                "regNum": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.SearchHitJSON": {
+            "type": "object",
+            "properties": {
+                "car": {
+                    "$ref": "#/definitions/api.CarJSON"
+                },
+                "highlight": {
+                    "description": "Highlight has the matched words wrapped in \u003cmark\u003e tags.",
+                    "type": "string"
+                },
+                "rank": {
+                    "type": "number"
+                }
+            }
+        },
+        "api.StatGroupJSON": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "name": {
+                    "description": "Name is the region name when grouping by region",
+                    "type": "string"
+                }
+            }
+        },
+        "api.StatsJSON": {
+            "type": "object",
+            "properties": {
+                "groupBy": {
+                    "type": "string"
+                },
+                "groups": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.StatGroupJSON"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.StatusRequestJSON": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "active",
+                        "sold",
+                        "stolen",
+                        "scrapped",
+                        "exported"
+                    ]
+                }
+            }
+        },
+        "api.SuggestionJSON": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.TransferRequestJSON": {
+            "type": "object",
+            "properties": {
+                "owner": {
+                    "$ref": "#/definitions/api.PeopleJSON"
+                }
+            }
+        },
+        "api.VINInfoJSON": {
+            "type": "object",
+            "properties": {
+                "country": {
+                    "type": "string"
+                },
+                "mark": {
+                    "type": "string"
+                },
+                "modelYear": {
+                    "type": "integer"
+                },
+                "vin": {
+                    "type": "string"
+                },
+                "wmi": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.VINMismatchJSON": {
+            "type": "object",
+            "properties": {
+                "decodedMark": {
+                    "type": "string"
+                },
+                "mark": {
+                    "type": "string"
+                },
+                "regNum": {
+                    "type": "string"
+                },
+                "vin": {
+                    "type": "string"
                 }
             }
         }
@@ -0,0 +1 @@
+ALTER TABLE Car DROP COLUMN IF EXISTS version;
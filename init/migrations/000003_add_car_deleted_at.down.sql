@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS car_deleted_at_idx;
+
+ALTER TABLE Car DROP COLUMN IF EXISTS deleted_at;
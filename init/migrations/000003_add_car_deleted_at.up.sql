@@ -0,0 +1,3 @@
+ALTER TABLE Car ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+
+CREATE INDEX IF NOT EXISTS car_deleted_at_idx ON Car (deleted_at) WHERE deleted_at IS NOT NULL;
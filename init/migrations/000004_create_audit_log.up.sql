@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS Audit_log (
+    id_a bigserial PRIMARY KEY,
+    entity varchar(20) NOT NULL,
+    entity_id varchar(60) NOT NULL,
+    action varchar(20) NOT NULL,
+    actor varchar(100) NOT NULL,
+    request_id varchar(100),
+    before_a jsonb,
+    after_a jsonb,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_entity_idx ON Audit_log (entity, entity_id, created_at);
+CREATE INDEX IF NOT EXISTS audit_log_created_at_idx ON Audit_log (created_at);
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS Ownership (
+    id_o bigserial PRIMARY KEY,
+    reg_num varchar(12) NOT NULL REFERENCES Car(reg_num) ON UPDATE CASCADE ON DELETE CASCADE,
+    id_p integer NOT NULL REFERENCES People(id_p),
+    valid_from timestamptz NOT NULL DEFAULT now(),
+    valid_to timestamptz,
+    CONSTRAINT valid_period CHECK (valid_to IS NULL OR valid_to >= valid_from)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS ownership_current_idx ON Ownership (reg_num) WHERE valid_to IS NULL;
+
+-- the real start of existing ownerships is unknown, they start with the migration
+INSERT INTO Ownership (reg_num, id_p)
+SELECT reg_num, id_p FROM Car WHERE id_p IS NOT NULL;
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS car_history_trigger ON Car;
+DROP FUNCTION IF EXISTS car_history_track();
+DROP TABLE IF EXISTS Car_history;
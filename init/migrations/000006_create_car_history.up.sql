@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS Car_history (
+    id_h bigserial PRIMARY KEY,
+    reg_num_h varchar(12) NOT NULL,
+    data jsonb NOT NULL,
+    valid_from timestamptz NOT NULL,
+    valid_to timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS car_history_reg_num_idx ON Car_history (reg_num_h, valid_from);
+CREATE INDEX IF NOT EXISTS car_history_period_idx ON Car_history (valid_from, valid_to);
+
+-- every version of a car row is kept with the period it was valid in
+CREATE OR REPLACE FUNCTION car_history_track() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        UPDATE Car_history SET valid_to = now()
+        WHERE reg_num_h = OLD.reg_num AND valid_to IS NULL;
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        INSERT INTO Car_history (reg_num_h, data, valid_from)
+        VALUES (NEW.reg_num, to_jsonb(NEW), now());
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS car_history_trigger ON Car;
+CREATE TRIGGER car_history_trigger AFTER INSERT OR UPDATE OR DELETE ON Car
+FOR EACH ROW EXECUTE FUNCTION car_history_track();
+
+INSERT INTO Car_history (reg_num_h, data, valid_from)
+SELECT reg_num, to_jsonb(Car), now() FROM Car;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS people_name_surname_prefix_idx;
+DROP INDEX IF EXISTS people_surname_name_prefix_idx;
+DROP INDEX IF EXISTS car_mark_model_prefix_idx;
+DROP INDEX IF EXISTS car_model_prefix_idx;
+DROP INDEX IF EXISTS car_mark_prefix_idx;
@@ -0,0 +1,7 @@
+-- prefix search for autocomplete is case insensitive, text_pattern_ops lets
+-- LIKE 'prefix%' use the indexes whatever the database collation is
+CREATE INDEX IF NOT EXISTS car_mark_prefix_idx ON Car (lower(mark) text_pattern_ops);
+CREATE INDEX IF NOT EXISTS car_model_prefix_idx ON Car (lower(model) text_pattern_ops);
+CREATE INDEX IF NOT EXISTS car_mark_model_prefix_idx ON Car (lower(mark), lower(model) text_pattern_ops);
+CREATE INDEX IF NOT EXISTS people_surname_name_prefix_idx ON People (lower(surname_p || ' ' || name_p) text_pattern_ops);
+CREATE INDEX IF NOT EXISTS people_name_surname_prefix_idx ON People (lower(name_p || ' ' || surname_p) text_pattern_ops);
@@ -0,0 +1,12 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- the expressions must stay the same as carSearchDoc and ownerSearchDoc in
+-- internal/database/search.go for the indexes to be used
+CREATE INDEX IF NOT EXISTS car_search_fts_idx ON Car
+USING gin (to_tsvector('simple', reg_num || ' ' || mark || ' ' || model));
+CREATE INDEX IF NOT EXISTS car_search_trgm_idx ON Car
+USING gin ((reg_num || ' ' || mark || ' ' || model) gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS people_search_fts_idx ON People
+USING gin (to_tsvector('simple', name_p || ' ' || surname_p || ' ' || coalesce(patronymic_p, '')));
+CREATE INDEX IF NOT EXISTS people_search_trgm_idx ON People
+USING gin ((name_p || ' ' || surname_p || ' ' || coalesce(patronymic_p, '')) gin_trgm_ops);
@@ -0,0 +1,3 @@
+-- renamed plates are not restored, the original spelling is not kept
+DROP TABLE IF EXISTS Plate_collision;
+DROP FUNCTION IF EXISTS normalize_plate(text);
@@ -0,0 +1,37 @@
+-- canonical plate: no whitespace, upper case, Cyrillic homoglyphs as Latin
+-- letters; must stay in line with plate.Normalize
+CREATE OR REPLACE FUNCTION normalize_plate(p text) RETURNS text AS $$
+    SELECT translate(upper(regexp_replace(p, '\s', '', 'g')), 'АВЕКМНОРСТУХавекмнорстух', 'ABEKMHOPCTYXABEKMHOPCTYX')
+$$ LANGUAGE sql IMMUTABLE STRICT;
+
+-- plates that become equal once normalized need a manual decision on which
+-- car to keep, so they are recorded and left untouched
+CREATE TABLE IF NOT EXISTS Plate_collision (
+    normalized varchar(12) NOT NULL,
+    reg_num varchar(12) NOT NULL,
+    detected_at timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (normalized, reg_num)
+);
+
+INSERT INTO Plate_collision (normalized, reg_num)
+SELECT normalize_plate(reg_num), reg_num FROM Car
+WHERE normalize_plate(reg_num) IN (
+    SELECT normalize_plate(reg_num) FROM Car GROUP BY 1 HAVING count(*) > 1
+)
+ON CONFLICT DO NOTHING;
+
+-- Ownership follows through ON UPDATE CASCADE
+UPDATE Car SET reg_num = normalize_plate(reg_num)
+WHERE reg_num <> normalize_plate(reg_num)
+    AND reg_num NOT IN (SELECT reg_num FROM Plate_collision);
+
+-- keep the timeline of renamed cars under the new plate
+UPDATE Car_history SET
+    reg_num_h = normalize_plate(reg_num_h),
+    data = jsonb_set(data, '{reg_num}', to_jsonb(normalize_plate(reg_num_h)))
+WHERE reg_num_h <> normalize_plate(reg_num_h)
+    AND reg_num_h NOT IN (SELECT reg_num FROM Plate_collision);
+
+UPDATE Audit_log SET entity_id = normalize_plate(entity_id)
+WHERE entity = 'car' AND entity_id <> normalize_plate(entity_id)
+    AND entity_id NOT IN (SELECT reg_num FROM Plate_collision);
@@ -0,0 +1,2 @@
+ALTER TABLE Car DROP COLUMN IF EXISTS region_code;
+ALTER TABLE Car DROP COLUMN IF EXISTS plate_type;
@@ -0,0 +1,17 @@
+ALTER TABLE Car ADD COLUMN IF NOT EXISTS plate_type varchar(20);
+ALTER TABLE Car ADD COLUMN IF NOT EXISTS region_code varchar(3);
+
+-- existing cars get the built in Russian formats of plate.RussianFormats in
+-- the same order, the first matching one wins; other plates stay without a type
+UPDATE Car SET plate_type = 'civil', region_code = substring(reg_num from '^[ABEKMHOPCTYX]\d{3}[ABEKMHOPCTYX]{2}(\d{2,3})$')
+WHERE plate_type IS NULL AND reg_num ~ '^[ABEKMHOPCTYX]\d{3}[ABEKMHOPCTYX]{2}\d{2,3}$';
+UPDATE Car SET plate_type = 'taxi', region_code = substring(reg_num from '^[ABEKMHOPCTYX]{2}\d{3}(\d{2,3})$')
+WHERE plate_type IS NULL AND reg_num ~ '^[ABEKMHOPCTYX]{2}\d{3}\d{2,3}$';
+UPDATE Car SET plate_type = 'trailer', region_code = substring(reg_num from '^[ABEKMHOPCTYX]{2}\d{4}(\d{2,3})$')
+WHERE plate_type IS NULL AND reg_num ~ '^[ABEKMHOPCTYX]{2}\d{4}\d{2,3}$';
+UPDATE Car SET plate_type = 'moto', region_code = substring(reg_num from '^\d{4}[ABEKMHOPCTYX]{2}(\d{2,3})$')
+WHERE plate_type IS NULL AND reg_num ~ '^\d{4}[ABEKMHOPCTYX]{2}\d{2,3}$';
+UPDATE Car SET plate_type = 'police', region_code = substring(reg_num from '^[ABEKMHOPCTYX]\d{4}(\d{2,3})$')
+WHERE plate_type IS NULL AND reg_num ~ '^[ABEKMHOPCTYX]\d{4}\d{2,3}$';
+UPDATE Car SET plate_type = 'diplomatic', region_code = substring(reg_num from '^\d{3}(?:CD\d|[DT]\d{3})(\d{2,3})$')
+WHERE plate_type IS NULL AND reg_num ~ '^\d{3}(?:CD\d|[DT]\d{3})\d{2,3}$';
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS car_vin_key;
+ALTER TABLE Car DROP COLUMN IF EXISTS vin;
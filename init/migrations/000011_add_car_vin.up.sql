@@ -0,0 +1,2 @@
+ALTER TABLE Car ADD COLUMN IF NOT EXISTS vin varchar(17);
+CREATE UNIQUE INDEX IF NOT EXISTS car_vin_key ON Car (vin);
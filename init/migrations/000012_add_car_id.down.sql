@@ -0,0 +1,27 @@
+DROP TABLE IF EXISTS Plate_change;
+
+CREATE OR REPLACE FUNCTION car_history_track() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        UPDATE Car_history SET valid_to = now()
+        WHERE reg_num_h = OLD.reg_num AND valid_to IS NULL;
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        INSERT INTO Car_history (reg_num_h, data, valid_from)
+        VALUES (NEW.reg_num, to_jsonb(NEW), now());
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP INDEX IF EXISTS car_history_car_idx;
+ALTER TABLE Car_history DROP COLUMN IF EXISTS id_c_h;
+
+ALTER TABLE Ownership DROP CONSTRAINT IF EXISTS ownership_reg_num_fkey;
+ALTER TABLE Car DROP CONSTRAINT IF EXISTS car_reg_num_key;
+ALTER TABLE Car DROP CONSTRAINT IF EXISTS car_pkey;
+ALTER TABLE Car ADD CONSTRAINT car_pkey PRIMARY KEY (reg_num);
+ALTER TABLE Ownership ADD CONSTRAINT ownership_reg_num_fkey
+    FOREIGN KEY (reg_num) REFERENCES Car(reg_num) ON UPDATE CASCADE ON DELETE CASCADE;
+
+ALTER TABLE Car DROP COLUMN IF EXISTS id_c;
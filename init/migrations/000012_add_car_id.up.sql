@@ -0,0 +1,40 @@
+-- cars get a surrogate key so that reg_num can change; it stays unique and
+-- Ownership keeps following it through ON UPDATE CASCADE
+ALTER TABLE Car ADD COLUMN IF NOT EXISTS id_c bigserial;
+
+ALTER TABLE Ownership DROP CONSTRAINT IF EXISTS ownership_reg_num_fkey;
+ALTER TABLE Car DROP CONSTRAINT IF EXISTS car_pkey;
+ALTER TABLE Car ADD CONSTRAINT car_pkey PRIMARY KEY (id_c);
+ALTER TABLE Car ADD CONSTRAINT car_reg_num_key UNIQUE (reg_num);
+ALTER TABLE Ownership ADD CONSTRAINT ownership_reg_num_fkey
+    FOREIGN KEY (reg_num) REFERENCES Car(reg_num) ON UPDATE CASCADE ON DELETE CASCADE;
+
+CREATE TABLE IF NOT EXISTS Plate_change (
+    id_pc bigserial PRIMARY KEY,
+    id_c bigint NOT NULL REFERENCES Car(id_c) ON DELETE CASCADE,
+    old_reg_num varchar(12) NOT NULL,
+    new_reg_num varchar(12) NOT NULL,
+    changed_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS plate_change_car_idx ON Plate_change (id_c, changed_at);
+CREATE INDEX IF NOT EXISTS plate_change_old_reg_num_idx ON Plate_change (old_reg_num, changed_at);
+
+-- versions of a car are linked by id_c, the plate may differ between them
+ALTER TABLE Car_history ADD COLUMN IF NOT EXISTS id_c_h bigint;
+UPDATE Car_history AS h SET id_c_h = c.id_c FROM Car AS c WHERE h.reg_num_h = c.reg_num;
+CREATE INDEX IF NOT EXISTS car_history_car_idx ON Car_history (id_c_h, valid_from);
+
+CREATE OR REPLACE FUNCTION car_history_track() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        UPDATE Car_history SET valid_to = now()
+        WHERE id_c_h = OLD.id_c AND valid_to IS NULL;
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        INSERT INTO Car_history (reg_num_h, id_c_h, data, valid_from)
+        VALUES (NEW.reg_num, NEW.id_c, to_jsonb(NEW), now());
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
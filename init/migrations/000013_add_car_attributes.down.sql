@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS car_mileage_idx;
+
+ALTER TABLE Car DROP COLUMN IF EXISTS mileage;
+ALTER TABLE Car DROP COLUMN IF EXISTS transmission;
+ALTER TABLE Car DROP COLUMN IF EXISTS engine_volume;
+ALTER TABLE Car DROP COLUMN IF EXISTS fuel;
+ALTER TABLE Car DROP COLUMN IF EXISTS body_type;
+ALTER TABLE Car DROP COLUMN IF EXISTS color;
@@ -0,0 +1,11 @@
+ALTER TABLE Car ADD COLUMN IF NOT EXISTS color varchar(30);
+ALTER TABLE Car ADD COLUMN IF NOT EXISTS body_type varchar(20) CONSTRAINT known_body_type
+    CHECK (body_type IN ('sedan', 'hatchback', 'wagon', 'coupe', 'convertible', 'suv', 'crossover', 'minivan', 'pickup', 'van', 'bus', 'truck', 'motorcycle'));
+ALTER TABLE Car ADD COLUMN IF NOT EXISTS fuel varchar(20) CONSTRAINT known_fuel
+    CHECK (fuel IN ('petrol', 'diesel', 'lpg', 'cng', 'hybrid', 'electric'));
+ALTER TABLE Car ADD COLUMN IF NOT EXISTS engine_volume integer CONSTRAINT positive_engine_volume CHECK (engine_volume > 0);
+ALTER TABLE Car ADD COLUMN IF NOT EXISTS transmission varchar(20) CONSTRAINT known_transmission
+    CHECK (transmission IN ('manual', 'automatic', 'robot', 'cvt'));
+ALTER TABLE Car ADD COLUMN IF NOT EXISTS mileage integer CONSTRAINT non_negative_mileage CHECK (mileage >= 0);
+
+CREATE INDEX IF NOT EXISTS car_mileage_idx ON Car (mileage);
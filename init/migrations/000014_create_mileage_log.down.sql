@@ -0,0 +1,3 @@
+ALTER TABLE Car DROP COLUMN IF EXISTS suspicious_rollback;
+
+DROP TABLE IF EXISTS Mileage_log;
@@ -0,0 +1,14 @@
+-- odometer readings of a car; a reading out of order by date is only kept
+-- when the client overrides the check, and then it marks the car
+CREATE TABLE IF NOT EXISTS Mileage_log (
+    id_ml bigserial PRIMARY KEY,
+    id_c bigint NOT NULL REFERENCES Car(id_c) ON DELETE CASCADE,
+    mileage integer NOT NULL CONSTRAINT non_negative_reading CHECK (mileage >= 0),
+    read_on date NOT NULL,
+    rollback boolean NOT NULL DEFAULT false,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS mileage_log_car_idx ON Mileage_log (id_c, read_on);
+
+ALTER TABLE Car ADD COLUMN IF NOT EXISTS suspicious_rollback boolean NOT NULL DEFAULT false;
@@ -0,0 +1,16 @@
+-- service and maintenance events of a car
+CREATE TABLE IF NOT EXISTS Maintenance (
+    id_m bigserial PRIMARY KEY,
+    id_c bigint NOT NULL REFERENCES Car(id_c) ON DELETE CASCADE,
+    done_on date NOT NULL,
+    mileage integer CONSTRAINT non_negative_service_mileage CHECK (mileage >= 0),
+    work_type varchar(30) NOT NULL CONSTRAINT known_work_type
+        CHECK (work_type IN ('service', 'oil_change', 'inspection', 'repair', 'tires', 'brakes', 'battery', 'bodywork', 'other')),
+    -- cost is kept in kopecks
+    cost bigint CONSTRAINT non_negative_cost CHECK (cost >= 0),
+    notes text,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS maintenance_car_idx ON Maintenance (id_c, done_on);
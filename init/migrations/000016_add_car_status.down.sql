@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS car_status_idx;
+
+ALTER TABLE Car DROP COLUMN IF EXISTS status_reason;
+ALTER TABLE Car DROP COLUMN IF EXISTS status;
@@ -0,0 +1,6 @@
+-- transitions between statuses are checked by the service
+ALTER TABLE Car ADD COLUMN IF NOT EXISTS status varchar(20) NOT NULL DEFAULT 'active' CONSTRAINT known_status
+    CHECK (status IN ('active', 'sold', 'stolen', 'scrapped', 'exported'));
+ALTER TABLE Car ADD COLUMN IF NOT EXISTS status_reason text;
+
+CREATE INDEX IF NOT EXISTS car_status_idx ON Car (status);
@@ -2,11 +2,13 @@ package api
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"reflect"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-playground/validator/v10"
@@ -14,26 +16,30 @@ import (
 	_ "github.com/mi-raf/cars-catalog/docs"
 	"github.com/mi-raf/cars-catalog/internal"
 	mod "github.com/mi-raf/cars-catalog/internal/models"
+	"github.com/mi-raf/cars-catalog/internal/plate"
 	"github.com/mi-raf/cars-catalog/internal/service"
 	"github.com/rs/zerolog/log"
 	echoSwagger "github.com/swaggo/echo-swagger"
 )
 
 const (
-	MAX_LIMIT    = 100
-	MIN_LIMIT    = 5
-	MIN_CAR_YEAR = 1885
+	MAX_LIMIT        = 100
+	MIN_LIMIT        = 5
+	MIN_CAR_YEAR     = 1885
+	MAX_BATCH_DELETE = 1000
 )
 
 type (
 	Config struct {
-		Addr string
+		Addr           string
+		RequireIfMatch bool
 	}
 
 	API struct {
-		e    *echo.Echo
-		s    *service.CarServise
-		addr string
+		e              *echo.Echo
+		s              *service.CarServise
+		addr           string
+		requireIfMatch bool
 	}
 
 	Context struct {
@@ -45,9 +51,10 @@ type (
 func New(ctx context.Context, cfg *Config, s *service.CarServise) (*API, error) {
 	e := echo.New()
 	a := &API{
-		s:    s,
-		e:    e,
-		addr: cfg.Addr,
+		s:              s,
+		e:              e,
+		addr:           cfg.Addr,
+		requireIfMatch: cfg.RequireIfMatch,
 	}
 
 	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
@@ -60,13 +67,40 @@ func New(ctx context.Context, cfg *Config, s *service.CarServise) (*API, error)
 		}
 	})
 
+	e.Use(auditMeta())
 	e.Use(logger())
 	e.GET("/health", healthCheck)
 	e.GET("/swagger/*", echoSwagger.WrapHandler)
 	e.GET("/car", a.getCarsWithFilter)
+	e.GET("/car/export", a.exportCars)
+	e.GET("/car/stats", a.getStats)
+	e.GET("/car/search", a.searchCars)
+	e.GET("/car/:regnum", a.getCar)
+	e.GET("/car/:regnum/history", a.getCarHistory)
+	e.GET("/car/:regnum/owners", a.getCarOwners)
+	e.POST("/car/:regnum/transfer", a.transferCar)
+	e.POST("/car/:regnum/replate", a.replateCar)
+	e.POST("/car/:regnum/status", a.changeCarStatus)
+	e.GET("/car/:regnum/plates", a.getCarPlates)
+	e.POST("/car/:regnum/mileage", a.addCarMileage)
+	e.GET("/car/:regnum/mileage", a.getCarMileage)
+	e.GET("/car/:regnum/maintenance", a.listMaintenance)
+	e.POST("/car/:regnum/maintenance", a.addMaintenance)
+	e.GET("/car/:regnum/maintenance/:id", a.getMaintenance)
+	e.PUT("/car/:regnum/maintenance/:id", a.updateMaintenance)
+	e.DELETE("/car/:regnum/maintenance/:id", a.deleteMaintenance)
+	e.GET("/audit", a.getAudit)
+	e.GET("/vin/:vin/decode", decodeVIN)
+	e.GET("/reports/catalog.xlsx", a.getCatalogReport)
+	e.GET("/suggest/mark", a.suggestMark)
+	e.GET("/suggest/model", a.suggestModel)
+	e.GET("/suggest/owner", a.suggestOwner)
+	e.DELETE("/car", a.deleteCars)
 	e.DELETE("/car/:regnum", a.deleteCar)
 	e.PATCH("/car", a.updateCar)
 	e.POST("/car", a.addCar)
+	e.POST("/car/import", a.importCars)
+	e.POST("/car/:regnum/restore", a.restoreCar)
 
 	return a, nil
 }
@@ -110,11 +144,34 @@ func healthCheck(e echo.Context) error {
 
 type (
 	CarJSON struct {
-		RegNum string      `json:"regNum" `
-		Mark   string      `json:"mark"`
-		Model  string      `json:"model"`
-		Year   int32       `json:"year,omitempty"`
-		Owner  *PeopleJSON `json:"owner"`
+		// Id is assigned by the catalog and ignored on input
+		Id        int64       `json:"id,omitempty"`
+		RegNum    string      `json:"regNum" `
+		Mark      string      `json:"mark"`
+		Model     string      `json:"model"`
+		Year      int32       `json:"year,omitempty"`
+		Owner     *PeopleJSON `json:"owner"`
+		DeletedAt *time.Time  `json:"deletedAt,omitempty"`
+		// plate type and region are detected from the plate, they are ignored on input
+		PlateType  string `json:"plateType,omitempty" enums:"civil,taxi,trailer,moto,police,diplomatic"`
+		RegionCode string `json:"regionCode,omitempty"`
+		RegionName string `json:"regionName,omitempty"`
+		Vin        string `json:"vin,omitempty"`
+
+		Color    string `json:"color,omitempty"`
+		BodyType string `json:"bodyType,omitempty" enums:"sedan,hatchback,wagon,coupe,convertible,suv,crossover,minivan,pickup,van,bus,truck,motorcycle"`
+		Fuel     string `json:"fuel,omitempty" enums:"petrol,diesel,lpg,cng,hybrid,electric"`
+		// EngineVolume is in cm3
+		EngineVolume int32  `json:"engineVolume,omitempty"`
+		Transmission string `json:"transmission,omitempty" enums:"manual,automatic,robot,cvt"`
+		// Mileage is in km
+		Mileage int32 `json:"mileage,omitempty"`
+		// SuspiciousRollback is set once a lower odometer reading was logged
+		SuspiciousRollback bool `json:"suspiciousRollback"`
+		// LastServiceOn is only present with expand=last_service
+		LastServiceOn string `json:"lastServiceOn,omitempty"`
+		Status        string `json:"status,omitempty" enums:"active,sold,stolen,scrapped,exported"`
+		StatusReason  string `json:"statusReason,omitempty"`
 	}
 
 	PeopleJSON struct {
@@ -126,11 +183,53 @@ type (
 	RegNumRequestJSON struct {
 		RegNums []string `json:"regNums"`
 	}
+
+	CarFilterJSON struct {
+		RegNum     string `json:"regNum,omitempty"`
+		Mark       string `json:"mark,omitempty"`
+		Model      string `json:"model,omitempty"`
+		Year       int32  `json:"year,omitempty"`
+		Name       string `json:"name,omitempty"`
+		Surname    string `json:"surname,omitempty"`
+		Patronymic string `json:"patronymic,omitempty"`
+		Region     string `json:"region,omitempty"`
+		Vin        string `json:"vin,omitempty"`
+
+		Color           string `json:"color,omitempty"`
+		BodyType        string `json:"bodyType,omitempty"`
+		Fuel            string `json:"fuel,omitempty"`
+		Transmission    string `json:"transmission,omitempty"`
+		MileageMin      int32  `json:"mileageMin,omitempty"`
+		MileageMax      int32  `json:"mileageMax,omitempty"`
+		EngineVolumeMin int32  `json:"engineVolumeMin,omitempty"`
+		EngineVolumeMax int32  `json:"engineVolumeMax,omitempty"`
+		Status          string `json:"status,omitempty"`
+
+		IncludeDeleted bool `json:"includeDeleted,omitempty"`
+	}
+
+	BatchDeleteRequestJSON struct {
+		RegNums []string       `json:"regNums,omitempty"`
+		Filter  *CarFilterJSON `json:"filter,omitempty"`
+		DryRun  bool           `json:"dryRun"`
+	}
+
+	DeleteResultJSON struct {
+		RegNum string `json:"regNum"`
+		Status string `json:"status" enums:"deleted,not_found"`
+	}
+
+	BatchDeleteResponseJSON struct {
+		DryRun  bool               `json:"dryRun"`
+		Deleted int                `json:"deleted"`
+		Results []DeleteResultJSON `json:"results"`
+	}
 )
 
 // @Summary Get cars with filter
-// @Description method to get some cars from database with filter and pagination. If filter is empty this method return all cars.
+// @Description method to get some cars from database with filter and pagination. If filter is empty this method return all cars. With Accept: text/csv or format=csv all matching cars are streamed as CSV ignoring pagination.
 // @Produce json
+// @Produce text/csv
 // @Success 200 {object} CarJSON
 // @Param limit query int false "limit of responce size"
 // @Param offset query int false "offset of responce for database"
@@ -141,6 +240,27 @@ type (
 // @Param name query string false "car's filter param owner's name"
 // @Param surname query string false "car's filter param owner's surname"
 // @Param patronymic query string false "car's filter param owner's patronymic"
+// @Param region query string false "car's filter param region code of the plate"
+// @Param vin query string false "car's filter param VIN"
+// @Param color query string false "car's filter param color, case insensitive"
+// @Param body_type query string false "car's filter param body type" Enums(sedan, hatchback, wagon, coupe, convertible, suv, crossover, minivan, pickup, van, bus, truck, motorcycle)
+// @Param fuel query string false "car's filter param fuel" Enums(petrol, diesel, lpg, cng, hybrid, electric)
+// @Param transmission query string false "car's filter param transmission" Enums(manual, automatic, robot, cvt)
+// @Param mileage_min query int false "car's filter param least mileage in km"
+// @Param mileage_max query int false "car's filter param greatest mileage in km"
+// @Param engine_volume_min query int false "car's filter param least engine volume in cm3"
+// @Param engine_volume_max query int false "car's filter param greatest engine volume in cm3"
+// @Param status query string false "car's filter param lifecycle status" Enums(active, sold, stolen, scrapped, exported)
+// @Param sort query string false "comma separated sort keys, prefix a key with - to sort descending: reg_num, mark, model, year, color, body_type, fuel, engine_volume, transmission, mileage"
+// @Param include_deleted query bool false "include soft deleted cars"
+// @Param as_of query string false "RFC 3339 time to get the catalog as it was at that instant"
+// @Param format query string false "csv to stream all matching cars as CSV instead of a page of JSON" Enums(json, csv)
+// @Param bom query bool false "prepend UTF-8 BOM to the CSV for Excel"
+// @Param expand query string false "comma separated extra data of the cars: last_service adds the date of the latest maintenance"
+// @Param facets query string false "comma separated facets counted for the filter without the facet's own condition: mark, model, year. The response becomes CarsWithFacetsJSON"
+// @Param If-None-Match header string false "ETag of a previously received listing"
+// @Header 200 {string} ETag "listing's entity tag"
+// @Success 304
 // @Failure      400  {string}  string    "error"
 // @Failure      500  {string}  string    "error"
 // @Router /car [get]
@@ -165,13 +285,125 @@ func (a *API) getCarsWithFilter(e echo.Context) error {
 		return err
 	}
 
+	filter, err := parseCarFilter(e)
+	if err != nil {
+		return err
+	}
+	filter.Sort = e.QueryParam("sort")
+	if err = parseExpand(e, &filter); err != nil {
+		return err
+	}
+
+	if wantsCSV(e) {
+		return a.exportCSV(e, filter)
+	}
+
+	facets, err := parseFacets(e)
+	if err != nil {
+		return err
+	}
+
+	var body []byte
+	if len(facets) > 0 {
+		body, err = a.carsWithFacets(cc.Ctx, filter, offset, limit, facets)
+	} else {
+		body, err = a.cars(cc.Ctx, filter, offset, limit)
+	}
+	if err != nil {
+		return httpError(err)
+	}
+	etag := contentETag(body)
+	e.Response().Header().Set(headerETag, etag)
+	if !noneMatch(e, etag) {
+		log.Debug().Str("etag", etag).Msg("cars not modified")
+		return e.NoContent(http.StatusNotModified)
+	}
+	return e.JSONBlob(http.StatusOK, body)
+}
+
+func (a *API) cars(ctx context.Context, filter mod.CarFilter, offset, limit int) ([]byte, error) {
+	cars, err := a.s.GetAll(ctx, filter, offset, limit)
+	if err != nil {
+		log.Error().Err(err).Msg("can't find cars")
+		return nil, err
+	}
+	log.Debug().Interface("filter", filter).Msg("get cars with filter")
+	carsJ := make([]CarJSON, 0, len(cars))
+	for _, c := range cars {
+		carsJ = append(carsJ, mapCarToJSON(&c))
+	}
+	return json.Marshal(carsJ)
+}
+
+// @Summary Get car by registration number
+// @Description method to get one car. The car's version is returned in the ETag header and can be sent back in If-Match on update or delete. A plate the car had before it was replated is redirected to the car.
+// @Produce json
+// @Success 200 {object} CarJSON
+// @Header 200 {string} ETag "car's version"
+// @Header 302 {string} Location "car's URL under its current plate"
+// @Param regnum path string true "car's registration number"
+// @Param as_of query string false "RFC 3339 time to get the car as it was at that instant"
+// @Failure      400  {string}  string    "error"
+// @Failure      404  {string}  string    "error"
+// @Failure      500  {string}  string    "error"
+// @Router /car/{regnum} [get]
+func (a *API) getCar(e echo.Context) error {
+	cc, err := getParentContext(e)
+	if err != nil {
+		log.Error().Err(err).Msg("can't get parent context in get car")
+		return err
+	}
+
+	asOf, err := parseAsOf(e)
+	if err != nil {
+		return err
+	}
+
+	regNum := e.Param("regnum")
+	car, err := a.s.Get(cc.Ctx, regNum, asOf)
+	if err != nil {
+		log.Debug().Err(err).Str("reg num", regNum).Msg("can't get car")
+		return a.redirectReplated(e, cc, regNum, err)
+	}
+	e.Response().Header().Set(headerETag, versionETag(car.Version))
+	return e.JSON(http.StatusOK, mapCarToJSON(car))
+}
+
+// parseCarFilter reads the car filter shared by listing endpoints from query params.
+func parseCarFilter(e echo.Context) (mod.CarFilter, error) {
 	year, err := safeAtoi(e.QueryParam("year"), func(i int) bool { return i >= MIN_CAR_YEAR && i <= time.Now().Year() })
 	if err != nil {
 		log.Debug().Err(err).Msg("incorrect year")
-		return err
+		return mod.CarFilter{}, err
+	}
+
+	includeDeleted, err := safeAtob(e.QueryParam("include_deleted"))
+	if err != nil {
+		log.Debug().Err(err).Msg("incorrect include_deleted")
+		return mod.CarFilter{}, err
+	}
+
+	asOf, err := parseAsOf(e)
+	if err != nil {
+		return mod.CarFilter{}, err
 	}
 
-	filter := mod.CarFilter{
+	status := strings.ToLower(strings.TrimSpace(e.QueryParam("status")))
+	if len(status) > 0 && !service.KnownStatus(status) {
+		log.Debug().Str("status", status).Msg("unknown status")
+		return mod.CarFilter{}, echo.NewHTTPError(http.StatusBadRequest, "unknown status: "+status)
+	}
+
+	nonNegative := func(i int) bool { return i >= 0 }
+	var bounds [4]int
+	for i, name := range []string{"mileage_min", "mileage_max", "engine_volume_min", "engine_volume_max"} {
+		if bounds[i], err = safeAtoi(e.QueryParam(name), nonNegative); err != nil {
+			log.Debug().Err(err).Msg("incorrect " + name)
+			return mod.CarFilter{}, err
+		}
+	}
+
+	return mod.CarFilter{
 		RegNum:     e.QueryParam("reg_num"),
 		Mark:       e.QueryParam("mark"),
 		Model:      e.QueryParam("model"),
@@ -179,20 +411,48 @@ func (a *API) getCarsWithFilter(e echo.Context) error {
 		Name:       e.QueryParam("name"),
 		Surname:    e.QueryParam("surname"),
 		Patronymic: e.QueryParam("patronymic"),
-	}
+		Region:     e.QueryParam("region"),
+		VIN:        e.QueryParam("vin"),
+
+		Color:           e.QueryParam("color"),
+		BodyType:        e.QueryParam("body_type"),
+		Fuel:            e.QueryParam("fuel"),
+		Transmission:    e.QueryParam("transmission"),
+		MileageMin:      int32(bounds[0]),
+		MileageMax:      int32(bounds[1]),
+		EngineVolumeMin: int32(bounds[2]),
+		EngineVolumeMax: int32(bounds[3]),
+		Status:          status,
+
+		IncludeDeleted: includeDeleted,
+		AsOf:           asOf,
+	}, nil
+}
 
-	cars, err := a.s.GetAll(cc.Ctx, filter, offset, limit)
-	if err != nil {
-		log.Error().Err(err).Msg("can't find cars")
-		return echo.ErrInternalServerError
+// parseAsOf reads the optional as_of query param used for temporal queries.
+func parseAsOf(e echo.Context) (time.Time, error) {
+	data := e.QueryParam("as_of")
+	if len(data) < 1 {
+		return time.Time{}, nil
 	}
-	log.Debug().Interface("filter", filter).Msg("get cars with filter")
-	carsJ := make([]CarJSON, 0, len(cars))
-	for _, c := range cars {
-		carsJ = append(carsJ, mapCarToJSON(&c))
+	asOf, err := time.Parse(time.RFC3339, data)
+	if err != nil {
+		log.Debug().Err(err).Str("data", data).Msg("can not parse as_of")
+		return time.Time{}, echo.NewHTTPError(http.StatusBadRequest, "as_of must be RFC 3339 time")
 	}
-	return e.JSON(http.StatusOK, carsJ)
+	return asOf, nil
+}
 
+func safeAtob(data string) (bool, error) {
+	if len(data) < 1 {
+		return false, nil
+	}
+	res, err := strconv.ParseBool(data)
+	if err != nil {
+		log.Debug().Err(err).Str("data", data).Msg("can not parse bool")
+		return false, echo.NewHTTPError(http.StatusBadRequest, "can not parse value: "+data)
+	}
+	return res, nil
 }
 
 func safeAtoi(data string, validator func(int) bool) (int, error) {
@@ -213,11 +473,13 @@ func safeAtoi(data string, validator func(int) bool) (int, error) {
 }
 
 // @Summary Add new cars.
+// @Description method to add cars known to the car API. When VIN checks are enabled and the mark of an added car does not match the manufacturer of its VIN, the cars are added and the mismatches are returned.
 // @Accept json
 // @Produce json
-// @Success 201
+// @Success 201 {object} AddCarsResponseJSON "only when there are VIN mismatches"
 // @Param body body RegNumRequestJSON true "new car's registraton number"
 // @Failure      400  {string}  string    "error"
+// @Failure      409  {string}  string    "error"
 // @Failure      500  {string}  string    "error"
 // @Router /car [post]
 func (a *API) addCar(e echo.Context) error {
@@ -234,7 +496,7 @@ func (a *API) addCar(e echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "Incorrect data")
 	}
 
-	err = a.s.AddAll(cc.Ctx, regsJ.RegNums)
+	mismatches, err := a.s.AddAll(cc.Ctx, regsJ.RegNums)
 	if _, ok := err.(validator.ValidationErrors); ok {
 		log.Debug().Err(err).Msg("Invalid data from client")
 		return echo.NewHTTPError(http.StatusBadRequest, "Invalid car data")
@@ -247,21 +509,34 @@ func (a *API) addCar(e echo.Context) error {
 		log.Debug().Err(err).Msg("Invalid response from client")
 		return echo.NewHTTPError(e.Code, e.Msg)
 	}
+	if errors.Is(err, internal.ErrDuplicateVIN) {
+		log.Debug().Err(err).Msg("VIN of new car is taken")
+		return httpError(err)
+	}
 	if err != nil {
 		log.Error().Err(err).Msg("can not add data")
 		return echo.ErrInternalServerError
 	}
 	log.Debug().Interface("cars", regsJ).Msg("cars add to database")
+	if len(mismatches) > 0 {
+		return e.JSON(http.StatusCreated, mapVINMismatchesToJSON(mismatches))
+	}
 	return e.NoContent(http.StatusCreated)
 
 }
 
 // @Summary Update new cars.
+// @Description mileage is logged as an odometer reading taken today; a mileage lower than the last reading is refused with 409, use POST /car/{regnum}/mileage with override to log a rollback.
 // @Accept json
 // @Produce json
 // @Success 200
 // @Param request body CarJSON true "new car's version "
+// @Param If-Match header string false "car's ETag from GET /car/{regnum}"
 // @Failure      400  {string}  string    "error"
+// @Failure      404  {string}  string    "error"
+// @Failure      409  {string}  string    "error"
+// @Failure      412  {string}  string    "error"
+// @Failure      428  {string}  string    "error"
 // @Failure      500  {string}  string    "error"
 // @Router /car [patch]
 func (a *API) updateCar(e echo.Context) error {
@@ -281,11 +556,17 @@ func (a *API) updateCar(e echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "can not unmarshall data")
 	}
 
+	version, err := ifMatchVersion(e, a.requireIfMatch)
+	if err != nil {
+		return err
+	}
+
 	car := mapJSONToCar(carJ)
+	car.Version = version
 	err = a.s.Update(cc.Ctx, &car)
 	if err != nil {
 		log.Error().Err(err).Msg("can not update data")
-		return echo.ErrInternalServerError
+		return httpError(err)
 	}
 	log.Debug().Interface("car", car).Msg("update car")
 	return e.NoContent(http.StatusOK)
@@ -295,10 +576,14 @@ func (a *API) updateCar(e echo.Context) error {
 // @Accept */*
 // @Produce json
 // @Success 200
-// @Param reg_num path string true "car's param registration number"
+// @Param regnum path string true "car's param registration number"
+// @Param If-Match header string false "car's ETag from GET /car/{regnum}"
 // @Failure      400  {string}  string    "error"
+// @Failure      404  {string}  string    "error"
+// @Failure      412  {string}  string    "error"
+// @Failure      428  {string}  string    "error"
 // @Failure      500  {string}  string    "error"
-// @Router /car [delete]
+// @Router /car/{regnum} [delete]
 func (a *API) deleteCar(e echo.Context) error {
 	cc, err := getParentContext(e)
 	if err != nil {
@@ -311,15 +596,142 @@ func (a *API) deleteCar(e echo.Context) error {
 		log.Error().Err(err).Msg("reg num is nil")
 		return echo.NewHTTPError(http.StatusBadRequest, "incorrect registration number")
 	}
-	err = a.s.Delete(cc.Ctx, regNum)
+	version, err := ifMatchVersion(e, a.requireIfMatch)
+	if err != nil {
+		return err
+	}
+	err = a.s.Delete(cc.Ctx, regNum, version)
 	if err != nil {
 		log.Error().Err(err).Str("mine regnum car", regNum).Msg("can't delete animal")
-		return echo.NewHTTPError(http.StatusInternalServerError)
+		return httpError(err)
 	}
 	log.Debug().Str("reg num", regNum).Msg("delete car")
 	return e.NoContent(http.StatusOK)
 }
 
+// @Summary Restore soft deleted car.
+// @Accept */*
+// @Produce json
+// @Success 200
+// @Param regnum path string true "car's registration number"
+// @Failure      404  {string}  string    "error"
+// @Failure      409  {string}  string    "error"
+// @Failure      500  {string}  string    "error"
+// @Router /car/{regnum}/restore [post]
+func (a *API) restoreCar(e echo.Context) error {
+	cc, err := getParentContext(e)
+	if err != nil {
+		log.Error().Err(err).Msg("can't get parent context in restore")
+		return err
+	}
+
+	regNum := e.Param("regnum")
+	err = a.s.Restore(cc.Ctx, regNum)
+	if err != nil {
+		log.Debug().Err(err).Str("reg num", regNum).Msg("can't restore car")
+		return httpError(err)
+	}
+	log.Debug().Str("reg num", regNum).Msg("restore car")
+	return e.NoContent(http.StatusOK)
+}
+
+// httpError maps errors from the service layer to HTTP errors.
+func httpError(err error) error {
+	var ve validator.ValidationErrors
+	switch {
+	case errors.Is(err, internal.ErrNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, "car not found")
+	case errors.Is(err, internal.ErrVersionMismatch):
+		return echo.NewHTTPError(http.StatusPreconditionFailed, "car was modified by another request")
+	case errors.Is(err, internal.ErrNotDeleted):
+		return echo.NewHTTPError(http.StatusConflict, "car is not deleted")
+	case errors.Is(err, internal.ErrSameOwner):
+		return echo.NewHTTPError(http.StatusConflict, "car already belongs to the owner")
+	case errors.Is(err, internal.ErrEmptyFilter):
+		return echo.NewHTTPError(http.StatusBadRequest, "filter must not be empty")
+	case errors.Is(err, internal.ErrTooManyMatches):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	case errors.Is(err, internal.ErrUnknownGroup):
+		return echo.NewHTTPError(http.StatusBadRequest, "unknown group_by")
+	case errors.Is(err, internal.ErrDuplicateVIN):
+		return echo.NewHTTPError(http.StatusConflict, "VIN belongs to another car")
+	case errors.Is(err, internal.ErrSamePlate):
+		return echo.NewHTTPError(http.StatusConflict, "car already has the plate")
+	case errors.Is(err, internal.ErrPlateTaken):
+		return echo.NewHTTPError(http.StatusConflict, "plate belongs to another car")
+	case errors.Is(err, internal.ErrBadTransition), errors.Is(err, internal.ErrStatusForbids):
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	case errors.Is(err, internal.ErrNoMaintenance):
+		return echo.NewHTTPError(http.StatusNotFound, "maintenance record not found")
+	case errors.Is(err, internal.ErrMileageRollback):
+		return echo.NewHTTPError(http.StatusConflict, "mileage is out of order with earlier readings, set override to log it")
+	case errors.Is(err, internal.ErrUnknownSort):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	case errors.Is(err, internal.ErrEmptyQuery):
+		return echo.NewHTTPError(http.StatusBadRequest, "query must contain letters or digits")
+	case errors.As(err, &ve):
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid car data")
+	}
+	return echo.ErrInternalServerError
+}
+
+// @Summary Delete several cars.
+// @Description method to delete cars by a list of registration numbers or by a filter in one transaction. A filter must not be empty and must not match more cars than the configured maximum, otherwise nothing is deleted. Cars are soft deleted and can be restored until they are purged. With dryRun the cars that would be deleted are reported and nothing is changed.
+// @Accept json
+// @Produce json
+// @Success 200 {object} BatchDeleteResponseJSON
+// @Param body body BatchDeleteRequestJSON true "registration numbers or filter"
+// @Failure      400  {string}  string    "error"
+// @Failure      500  {string}  string    "error"
+// @Router /car [delete]
+func (a *API) deleteCars(e echo.Context) error {
+	cc, err := getParentContext(e)
+	if err != nil {
+		log.Error().Err(err).Msg("can't get parent context in batch delete")
+		return err
+	}
+
+	reqJ := &BatchDeleteRequestJSON{}
+	if err = e.Bind(reqJ); err != nil {
+		log.Debug().Err(err).Msg("can not unmarshall data")
+		return echo.NewHTTPError(http.StatusBadRequest, "Incorrect data")
+	}
+	if (len(reqJ.RegNums) > 0) == (reqJ.Filter != nil) {
+		log.Debug().Interface("request", reqJ).Msg("regNums and filter are both set or both empty")
+		return echo.NewHTTPError(http.StatusBadRequest, "either regNums or filter must be set")
+	}
+	if len(reqJ.RegNums) > MAX_BATCH_DELETE {
+		log.Debug().Int("count", len(reqJ.RegNums)).Msg("too many reg nums")
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("at most %d registration numbers can be deleted at once", MAX_BATCH_DELETE))
+	}
+	req := mod.BatchDelete{
+		RegNums: reqJ.RegNums,
+		DryRun:  reqJ.DryRun,
+	}
+	if reqJ.Filter != nil {
+		f := mapJSONToFilter(reqJ.Filter)
+		req.Filter = &f
+	}
+
+	results, err := a.s.DeleteBatch(cc.Ctx, req)
+	if err != nil {
+		log.Error().Err(err).Msg("can't delete cars")
+		return httpError(err)
+	}
+	respJ := BatchDeleteResponseJSON{
+		DryRun:  reqJ.DryRun,
+		Results: make([]DeleteResultJSON, 0, len(results)),
+	}
+	for _, r := range results {
+		if r.Status == mod.DeleteStatusDeleted {
+			respJ.Deleted++
+		}
+		respJ.Results = append(respJ.Results, DeleteResultJSON{RegNum: r.RegNum, Status: r.Status})
+	}
+	log.Debug().Int("deleted", respJ.Deleted).Bool("dry run", respJ.DryRun).Msg("batch delete")
+	return e.JSON(http.StatusOK, respJ)
+}
+
 func getParentContext(e echo.Context) (*Context, error) {
 	cc, ok := e.(*Context)
 	if !ok {
@@ -355,7 +767,15 @@ func mapJSONToCar(cJson *CarJSON) mod.CarDTO {
 		Mark:   cJson.Mark,
 		Model:  cJson.Model,
 		Year:   cJson.Year,
+		VIN:    cJson.Vin,
 		Owner:  &owner,
+
+		Color:        cJson.Color,
+		BodyType:     cJson.BodyType,
+		Fuel:         cJson.Fuel,
+		EngineVolume: cJson.EngineVolume,
+		Transmission: cJson.Transmission,
+		Mileage:      cJson.Mileage,
 	}
 	return car
 }
@@ -368,11 +788,59 @@ func mapCarToJSON(car *mod.CarDTO) CarJSON {
 		Patronymic: car.Owner.Patronymic,
 	}
 	carJ := CarJSON{
-		RegNum: car.RegNum,
-		Mark:   car.Mark,
-		Model:  car.Model,
-		Year:   car.Year,
-		Owner:  &owner,
+		Id:         car.ID,
+		RegNum:     car.RegNum,
+		Mark:       car.Mark,
+		Model:      car.Model,
+		Year:       car.Year,
+		Owner:      &owner,
+		PlateType:  car.PlateType,
+		RegionCode: car.RegionCode,
+		RegionName: plate.RegionName(car.RegionCode),
+		Vin:        car.VIN,
+
+		Color:        car.Color,
+		BodyType:     car.BodyType,
+		Fuel:         car.Fuel,
+		EngineVolume: car.EngineVolume,
+		Transmission: car.Transmission,
+		Mileage:      car.Mileage,
+
+		SuspiciousRollback: car.SuspiciousRollback,
+		Status:             car.Status,
+		StatusReason:       car.StatusReason,
+	}
+	if !car.LastServiceOn.IsZero() {
+		carJ.LastServiceOn = car.LastServiceOn.Format(dateLayout)
+	}
+	if !car.DeletedAt.IsZero() {
+		carJ.DeletedAt = &car.DeletedAt
 	}
 	return carJ
 }
+
+func mapJSONToFilter(fJson *CarFilterJSON) mod.CarFilter {
+	return mod.CarFilter{
+		RegNum:     fJson.RegNum,
+		Mark:       fJson.Mark,
+		Model:      fJson.Model,
+		Year:       fJson.Year,
+		Name:       fJson.Name,
+		Surname:    fJson.Surname,
+		Patronymic: fJson.Patronymic,
+		Region:     fJson.Region,
+		VIN:        fJson.Vin,
+
+		Color:           fJson.Color,
+		BodyType:        fJson.BodyType,
+		Fuel:            fJson.Fuel,
+		Transmission:    fJson.Transmission,
+		MileageMin:      fJson.MileageMin,
+		MileageMax:      fJson.MileageMax,
+		EngineVolumeMin: fJson.EngineVolumeMin,
+		EngineVolumeMax: fJson.EngineVolumeMax,
+		Status:          fJson.Status,
+
+		IncludeDeleted: fJson.IncludeDeleted,
+	}
+}
@@ -0,0 +1,157 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mi-raf/cars-catalog/internal/audit"
+	mod "github.com/mi-raf/cars-catalog/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	headerActor = "X-Actor"
+
+	anonymousActor  = "anonymous"
+	MAX_AUDIT_LIMIT = 1000
+)
+
+type (
+	AuditRecordJSON struct {
+		ID        int64           `json:"id"`
+		Entity    string          `json:"entity"`
+		EntityID  string          `json:"entityId"`
+		Action    string          `json:"action"`
+		Actor     string          `json:"actor"`
+		RequestID string          `json:"requestId,omitempty"`
+		Before    json.RawMessage `json:"before,omitempty" swaggertype:"object"`
+		After     json.RawMessage `json:"after,omitempty" swaggertype:"object"`
+		CreatedAt time.Time       `json:"createdAt"`
+	}
+)
+
+// auditMeta attaches the actor and request id to the request's context so
+// that every change made while serving it can be attributed. A request id is
+// generated when the client did not send one.
+//
+// The actor is taken from the X-Actor header as sent; the service does no
+// authentication, so any client can claim to be anyone. The header is only
+// trustworthy when a proxy in front of the service authenticates the caller
+// and sets it, dropping the value sent by the client.
+func auditMeta() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(e echo.Context) error {
+			cc, err := getParentContext(e)
+			if err != nil {
+				return err
+			}
+
+			reqID := e.Request().Header.Get(echo.HeaderXRequestID)
+			if len(reqID) < 1 {
+				reqID = newRequestID()
+			}
+			e.Response().Header().Set(echo.HeaderXRequestID, reqID)
+
+			actor := strings.TrimSpace(e.Request().Header.Get(headerActor))
+			if len(actor) < 1 {
+				actor = anonymousActor
+			}
+			cc.Ctx = audit.WithMeta(cc.Ctx, audit.Meta{Actor: actor, RequestID: reqID})
+			return next(e)
+		}
+	}
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		log.Error().Err(err).Msg("can't generate request id")
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// @Summary Get change history of a car.
+// @Description method to get every recorded change of the car with its state before and after the change. The actor of a change is the X-Actor header of its request taken as sent, it is not authenticated by the service.
+// @Produce json
+// @Success 200 {array} AuditRecordJSON
+// @Param regnum path string true "car's registration number"
+// @Failure      500  {string}  string    "error"
+// @Router /car/{regnum}/history [get]
+func (a *API) getCarHistory(e echo.Context) error {
+	cc, err := getParentContext(e)
+	if err != nil {
+		log.Error().Err(err).Msg("can't get parent context in history")
+		return err
+	}
+
+	regNum := e.Param("regnum")
+	records, err := a.s.History(cc.Ctx, regNum)
+	if err != nil {
+		log.Error().Err(err).Str("reg num", regNum).Msg("can't get car history")
+		return httpError(err)
+	}
+	return e.JSON(http.StatusOK, mapAuditToJSON(records))
+}
+
+// @Summary Get audit log.
+// @Description method to get changes of all cars and owners made since the given time. The actor of a change is the X-Actor header of its request taken as sent, it is not authenticated by the service, so it can only be trusted when a proxy in front of the service sets the header.
+// @Produce json
+// @Success 200 {array} AuditRecordJSON
+// @Param since query string true "RFC 3339 time"
+// @Param limit query int false "limit of responce size"
+// @Failure      400  {string}  string    "error"
+// @Failure      500  {string}  string    "error"
+// @Router /audit [get]
+func (a *API) getAudit(e echo.Context) error {
+	cc, err := getParentContext(e)
+	if err != nil {
+		log.Error().Err(err).Msg("can't get parent context in audit")
+		return err
+	}
+
+	since, err := time.Parse(time.RFC3339, e.QueryParam("since"))
+	if err != nil {
+		log.Debug().Err(err).Msg("incorrect since")
+		return echo.NewHTTPError(http.StatusBadRequest, "since must be RFC 3339 time")
+	}
+	limit, err := safeAtoi(e.QueryParam("limit"), func(i int) bool { return i > 0 })
+	if err != nil {
+		log.Debug().Err(err).Msg("incorrect limit")
+		return err
+	}
+	if limit == 0 {
+		limit = MAX_LIMIT
+	}
+	limit = min(limit, MAX_AUDIT_LIMIT)
+
+	records, err := a.s.AuditSince(cc.Ctx, since, limit)
+	if err != nil {
+		log.Error().Err(err).Msg("can't get audit")
+		return httpError(err)
+	}
+	return e.JSON(http.StatusOK, mapAuditToJSON(records))
+}
+
+func mapAuditToJSON(records []mod.AuditRecord) []AuditRecordJSON {
+	res := make([]AuditRecordJSON, 0, len(records))
+	for _, r := range records {
+		res = append(res, AuditRecordJSON{
+			ID:        r.ID,
+			Entity:    r.Entity,
+			EntityID:  r.EntityID,
+			Action:    r.Action,
+			Actor:     r.Actor,
+			RequestID: r.RequestID,
+			Before:    r.Before,
+			After:     r.After,
+			CreatedAt: r.CreatedAt,
+		})
+	}
+	return res
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	headerETag        = "ETag"
+	headerIfMatch     = "If-Match"
+	headerIfNoneMatch = "If-None-Match"
+)
+
+// versionETag renders a car version as a strong entity tag.
+func versionETag(version int32) string {
+	return strconv.Quote(strconv.FormatInt(int64(version), 10))
+}
+
+// ifMatchVersion extracts the expected car version from the If-Match header.
+// Zero means the request is unconditional ("*" or no header when allowed).
+func ifMatchVersion(e echo.Context, required bool) (int32, error) {
+	h := strings.TrimSpace(e.Request().Header.Get(headerIfMatch))
+	if len(h) < 1 {
+		if required {
+			log.Debug().Msg("If-Match header is missing")
+			return 0, echo.NewHTTPError(http.StatusPreconditionRequired, "If-Match header is required")
+		}
+		return 0, nil
+	}
+	if h == "*" {
+		return 0, nil
+	}
+	if strings.HasPrefix(h, "W/") {
+		log.Debug().Str("if-match", h).Msg("weak etag in If-Match")
+		return 0, echo.NewHTTPError(http.StatusPreconditionFailed, "weak entity tags can not be used with If-Match")
+	}
+	raw, err := strconv.Unquote(h)
+	if err != nil {
+		log.Debug().Err(err).Str("if-match", h).Msg("can not unquote etag")
+		return 0, echo.NewHTTPError(http.StatusBadRequest, "incorrect If-Match header")
+	}
+	v, err := strconv.ParseInt(raw, 10, 32)
+	if err != nil || v < 1 {
+		log.Debug().Err(err).Str("if-match", h).Msg("can not parse etag")
+		return 0, echo.NewHTTPError(http.StatusPreconditionFailed, "entity tag does not match")
+	}
+	return int32(v), nil
+}
+
+// contentETag derives a strong entity tag from a response body.
+func contentETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return strconv.Quote(hex.EncodeToString(sum[:16]))
+}
+
+// noneMatch reports whether the If-None-Match header lets the full response
+// through, i.e. none of the listed tags matches etag. Comparison is weak as
+// required for If-None-Match.
+func noneMatch(e echo.Context, etag string) bool {
+	h := strings.TrimSpace(e.Request().Header.Get(headerIfNoneMatch))
+	if len(h) < 1 {
+		return true
+	}
+	for _, t := range strings.Split(h, ",") {
+		t = strings.TrimPrefix(strings.TrimSpace(t), "W/")
+		if t == "*" || t == etag {
+			return false
+		}
+	}
+	return true
+}
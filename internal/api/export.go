@@ -0,0 +1,229 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	mod "github.com/mi-raf/cars-catalog/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	mimeTextCSV = "text/csv"
+
+	// EXPORT_FLUSH_ROWS is how many rows are buffered before they are sent to the client.
+	EXPORT_FLUSH_ROWS = 500
+)
+
+var (
+	csvHeader = []string{"regNum", "mark", "model", "year", "ownerName", "ownerSurname", "ownerPatronymic", "deletedAt", "vin",
+		"color", "bodyType", "fuel", "engineVolume", "transmission", "mileage", "status"}
+	utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+)
+
+// wantsCSV reports whether the client asked for CSV with the format query
+// param or, when it is absent, with the Accept header.
+func wantsCSV(e echo.Context) bool {
+	if f := e.QueryParam("format"); len(f) > 0 {
+		return strings.EqualFold(f, "csv")
+	}
+	for _, part := range strings.Split(e.Request().Header.Get(echo.HeaderAccept), ",") {
+		t, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err == nil && t == mimeTextCSV {
+			return true
+		}
+	}
+	return false
+}
+
+// streamWriter commits a streamed response with its first write: the
+// headers are set and the 200 status is sent only then. Until data is written
+// an error can still be answered with its own status.
+type streamWriter struct {
+	res     *echo.Response
+	headers map[string]string
+}
+
+func newStreamWriter(res *echo.Response, headers map[string]string) *streamWriter {
+	return &streamWriter{res: res, headers: headers}
+}
+
+func (w *streamWriter) Write(p []byte) (int, error) {
+	if !w.res.Committed {
+		for k, v := range w.headers {
+			w.res.Header().Set(k, v)
+		}
+		w.res.WriteHeader(http.StatusOK)
+	}
+	return w.res.Write(p)
+}
+
+// exportCSV streams all cars matching the filter as CSV. Once the first rows
+// are sent the status can not be changed, so later errors only abort the body.
+func (a *API) exportCSV(e echo.Context, filter mod.CarFilter) error {
+	bom, err := safeAtob(e.QueryParam("bom"))
+	if err != nil {
+		log.Debug().Err(err).Msg("incorrect bom")
+		return err
+	}
+
+	res := e.Response()
+	bw := bufio.NewWriter(newStreamWriter(res, map[string]string{
+		echo.HeaderContentType:        mimeTextCSV + "; charset=utf-8",
+		echo.HeaderContentDisposition: `attachment; filename="cars.csv"`,
+	}))
+	if bom {
+		if _, err = bw.Write(utf8BOM); err != nil {
+			return err
+		}
+	}
+
+	w := csv.NewWriter(bw)
+	if err = w.Write(csvHeader); err != nil {
+		return err
+	}
+	rows := 0
+	err = a.s.ForEach(e.Request().Context(), filter, func(c *mod.CarDTO) error {
+		if err := w.Write(carToCSV(c)); err != nil {
+			return err
+		}
+		rows++
+		if rows%EXPORT_FLUSH_ROWS == 0 {
+			w.Flush()
+			if err := bw.Flush(); err != nil {
+				return err
+			}
+			res.Flush()
+		}
+		return w.Error()
+	})
+	if err != nil {
+		return exportError(res, err, rows, "csv")
+	}
+	w.Flush()
+	if err = w.Error(); err == nil {
+		err = bw.Flush()
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("can't flush csv")
+		return nil
+	}
+	log.Debug().Interface("filter", filter).Int("rows", rows).Msg("export cars to csv")
+	return nil
+}
+
+// @Summary Export cars as NDJSON.
+// @Description method to stream all cars matching the filter, one JSON object per line, without pagination. The stream is aborted when the client disconnects.
+// @Produce application/x-ndjson
+// @Success 200 {object} CarJSON
+// @Param year query int false "car's filter param year"
+// @Param reg_num query string false "car's filter param registration number"
+// @Param mark query string false "car's filter param mark"
+// @Param model query string false "car's filter param model"
+// @Param name query string false "car's filter param owner's name"
+// @Param surname query string false "car's filter param owner's surname"
+// @Param patronymic query string false "car's filter param owner's patronymic"
+// @Param region query string false "car's filter param region code of the plate"
+// @Param vin query string false "car's filter param VIN"
+// @Param color query string false "car's filter param color, case insensitive"
+// @Param body_type query string false "car's filter param body type" Enums(sedan, hatchback, wagon, coupe, convertible, suv, crossover, minivan, pickup, van, bus, truck, motorcycle)
+// @Param fuel query string false "car's filter param fuel" Enums(petrol, diesel, lpg, cng, hybrid, electric)
+// @Param transmission query string false "car's filter param transmission" Enums(manual, automatic, robot, cvt)
+// @Param mileage_min query int false "car's filter param least mileage in km"
+// @Param mileage_max query int false "car's filter param greatest mileage in km"
+// @Param engine_volume_min query int false "car's filter param least engine volume in cm3"
+// @Param engine_volume_max query int false "car's filter param greatest engine volume in cm3"
+// @Param status query string false "car's filter param lifecycle status" Enums(active, sold, stolen, scrapped, exported)
+// @Param include_deleted query bool false "include soft deleted cars"
+// @Param as_of query string false "RFC 3339 time to get the catalog as it was at that instant"
+// @Failure      400  {string}  string    "error"
+// @Router /car/export [get]
+func (a *API) exportCars(e echo.Context) error {
+	filter, err := parseCarFilter(e)
+	if err != nil {
+		return err
+	}
+
+	// rows go straight from the database cursor to the client; json.Encoder
+	// terminates every value with a newline
+	res := e.Response()
+	w := bufio.NewWriter(newStreamWriter(res, map[string]string{echo.HeaderContentType: mimeNDJSON + "; charset=utf-8"}))
+	enc := json.NewEncoder(w)
+	rows := 0
+	err = a.s.ForEach(e.Request().Context(), filter, func(c *mod.CarDTO) error {
+		if err := enc.Encode(mapCarToJSON(c)); err != nil {
+			return err
+		}
+		rows++
+		if rows%EXPORT_FLUSH_ROWS == 0 {
+			if err := w.Flush(); err != nil {
+				return err
+			}
+			res.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return exportError(res, err, rows, "ndjson")
+	}
+	if err = w.Flush(); err != nil {
+		log.Error().Err(err).Msg("can't flush ndjson")
+		return nil
+	}
+	log.Debug().Interface("filter", filter).Int("rows", rows).Msg("export cars to ndjson")
+	return nil
+}
+
+// exportError reports a failed stream. While nothing was sent the error is
+// answered as usual; afterwards the client only sees a truncated body. A
+// disconnect is not an error.
+func exportError(res *echo.Response, err error, rows int, format string) error {
+	if errors.Is(err, context.Canceled) {
+		log.Debug().Int("rows", rows).Str("format", format).Msg("client cancelled export")
+		return nil
+	}
+	log.Error().Err(err).Int("rows", rows).Str("format", format).Msg("can't export cars")
+	if res.Committed {
+		return nil
+	}
+	return httpError(err)
+}
+
+// carToCSV flattens a car and its owner into one CSV record.
+func carToCSV(c *mod.CarDTO) []string {
+	rec := make([]string, len(csvHeader))
+	rec[0] = c.RegNum
+	rec[1] = c.Mark
+	rec[2] = c.Model
+	if c.Year > 0 {
+		rec[3] = strconv.FormatInt(int64(c.Year), 10)
+	}
+	if c.Owner != nil {
+		rec[4] = c.Owner.Name
+		rec[5] = c.Owner.Surname
+		rec[6] = c.Owner.Patronymic
+	}
+	if !c.DeletedAt.IsZero() {
+		rec[7] = c.DeletedAt.UTC().Format(time.RFC3339)
+	}
+	rec[8] = c.VIN
+	rec[9], rec[10], rec[11] = c.Color, c.BodyType, c.Fuel
+	if c.EngineVolume > 0 {
+		rec[12] = strconv.FormatInt(int64(c.EngineVolume), 10)
+	}
+	rec[13] = c.Transmission
+	if c.Mileage > 0 {
+		rec[14] = strconv.FormatInt(int64(c.Mileage), 10)
+	}
+	rec[15] = c.Status
+	return rec
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	mod "github.com/mi-raf/cars-catalog/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+type (
+	FacetValueJSON struct {
+		Value string `json:"value"`
+		Count int64  `json:"count"`
+	}
+
+	CarsWithFacetsJSON struct {
+		Cars   []CarJSON                   `json:"cars"`
+		Facets map[string][]FacetValueJSON `json:"facets"`
+	}
+)
+
+var knownFacets = map[string]bool{mod.FacetMark: true, mod.FacetModel: true, mod.FacetYear: true}
+
+// parseFacets reads the comma separated facets query param dropping repeats.
+func parseFacets(e echo.Context) ([]string, error) {
+	data := e.QueryParam("facets")
+	if len(data) < 1 {
+		return nil, nil
+	}
+	var facets []string
+	seen := map[string]bool{}
+	for _, f := range strings.Split(data, ",") {
+		f = strings.TrimSpace(f)
+		if !knownFacets[f] {
+			log.Debug().Str("facet", f).Msg("unknown facet")
+			return nil, echo.NewHTTPError(http.StatusBadRequest, "unknown facet: "+f)
+		}
+		if !seen[f] {
+			seen[f] = true
+			facets = append(facets, f)
+		}
+	}
+	return facets, nil
+}
+
+func (a *API) carsWithFacets(ctx context.Context, filter mod.CarFilter, offset, limit int, facets []string) ([]byte, error) {
+	cars, counts, err := a.s.GetAllWithFacets(ctx, filter, offset, limit, facets)
+	if err != nil {
+		log.Error().Err(err).Msg("can't find cars with facets")
+		return nil, err
+	}
+	res := CarsWithFacetsJSON{
+		Cars:   make([]CarJSON, 0, len(cars)),
+		Facets: make(map[string][]FacetValueJSON, len(counts)),
+	}
+	for _, c := range cars {
+		res.Cars = append(res.Cars, mapCarToJSON(&c))
+	}
+	for name, groups := range counts {
+		values := make([]FacetValueJSON, 0, len(groups))
+		for _, g := range groups {
+			values = append(values, FacetValueJSON{Value: g.Key, Count: g.Count})
+		}
+		res.Facets[name] = values
+	}
+	return json.Marshal(res)
+}
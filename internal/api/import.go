@@ -0,0 +1,282 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	mod "github.com/mi-raf/cars-catalog/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	mimeNDJSON = "application/x-ndjson"
+
+	MAX_IMPORT_ROWS = 100000
+	// MAX_NDJSON_LINE limits the length of one uploaded NDJSON line in bytes.
+	MAX_NDJSON_LINE = 64 * 1024
+)
+
+// csvRequiredColumns must be present in the header of an uploaded CSV.
+var csvRequiredColumns = []string{"regNum", "mark", "model", "ownerName", "ownerSurname"}
+
+type (
+	ImportErrorJSON struct {
+		Line   int    `json:"line"`
+		RegNum string `json:"regNum,omitempty"`
+		Error  string `json:"error"`
+	}
+
+	ImportReportJSON struct {
+		DryRun   bool              `json:"dryRun"`
+		Total    int               `json:"total"`
+		Valid    int               `json:"valid"`
+		Imported int               `json:"imported"`
+		Skipped  []string          `json:"skipped"`
+		Errors   []ImportErrorJSON `json:"errors"`
+		// Failed is only present when a batch could not be saved
+		Failed *ImportFailureJSON `json:"failed,omitempty"`
+	}
+
+	ImportFailureJSON struct {
+		FromLine int    `json:"fromLine"`
+		ToLine   int    `json:"toLine"`
+		Error    string `json:"error"`
+	}
+)
+
+// @Summary Import cars from a file.
+// @Description method to add manually curated cars from CSV (same columns as the CSV export, header required) or NDJSON (one car object per line). Every row is validated and invalid rows are reported by line number; valid rows are committed in batches. When a batch can not be saved the batches before it stay committed, the import stops and the report with the failed line range is returned with status 500. Cars that already exist or whose VIN belongs to another car are skipped. With dry_run nothing is changed.
+// @Accept text/csv
+// @Accept application/x-ndjson
+// @Produce json
+// @Success 200 {object} ImportReportJSON
+// @Param dry_run query bool false "validate and report without saving"
+// @Failure      400  {string}  string    "error"
+// @Failure      413  {string}  string    "error"
+// @Failure      415  {string}  string    "error"
+// @Failure      500  {object}  ImportReportJSON    "report of the batches committed before the failed one"
+// @Router /car/import [post]
+func (a *API) importCars(e echo.Context) error {
+	cc, err := getParentContext(e)
+	if err != nil {
+		log.Error().Err(err).Msg("can't get parent context in import")
+		return err
+	}
+
+	dryRun, err := safeAtob(e.QueryParam("dry_run"))
+	if err != nil {
+		log.Debug().Err(err).Msg("incorrect dry_run")
+		return err
+	}
+
+	ct, _, err := mime.ParseMediaType(e.Request().Header.Get(echo.HeaderContentType))
+	if err != nil {
+		log.Debug().Err(err).Msg("can't parse content type")
+		return echo.ErrUnsupportedMediaType
+	}
+	var rows []mod.ImportRow
+	var parseErrs []mod.ImportError
+	switch ct {
+	case mimeTextCSV:
+		rows, parseErrs, err = readImportCSV(e.Request().Body)
+	case mimeNDJSON:
+		rows, parseErrs, err = readImportNDJSON(e.Request().Body)
+	default:
+		log.Debug().Str("content type", ct).Msg("unsupported import format")
+		return echo.ErrUnsupportedMediaType
+	}
+	if err != nil {
+		return err
+	}
+
+	report, err := a.s.Import(cc.Ctx, rows, dryRun)
+	if err != nil && report.Failed == nil {
+		log.Error().Err(err).Msg("can't import cars")
+		return httpError(err)
+	}
+	report.Total += len(parseErrs)
+	report.Errors = append(parseErrs, report.Errors...)
+	sort.SliceStable(report.Errors, func(i, j int) bool { return report.Errors[i].Line < report.Errors[j].Line })
+	if err != nil {
+		// earlier batches are committed, the client needs to know which
+		log.Error().Err(err).Int("imported", len(report.Imported)).Int("failed from", report.Failed.FromLine).Msg("can't import cars")
+		return e.JSON(http.StatusInternalServerError, mapImportReportToJSON(&report))
+	}
+	return e.JSON(http.StatusOK, mapImportReportToJSON(&report))
+}
+
+// readImportCSV maps the columns by the header row, so their order is free
+// and unknown columns are ignored. Syntax errors abort the import as the rest
+// of the file can not be read reliably.
+func readImportCSV(body io.Reader) ([]mod.ImportRow, []mod.ImportError, error) {
+	r := csv.NewReader(body)
+	r.FieldsPerRecord = -1
+	header, err := r.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil, echo.NewHTTPError(http.StatusBadRequest, "CSV header is missing")
+	}
+	if err != nil {
+		log.Debug().Err(err).Msg("can't read csv header")
+		return nil, nil, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], string(utf8BOM))
+	}
+	cols := make(map[string]int, len(header))
+	for i, h := range header {
+		cols[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	for _, c := range csvRequiredColumns {
+		if _, ok := cols[strings.ToLower(c)]; !ok {
+			return nil, nil, echo.NewHTTPError(http.StatusBadRequest, "CSV column is missing: "+c)
+		}
+	}
+	field := func(rec []string, name string) string {
+		if i, ok := cols[strings.ToLower(name)]; ok && i < len(rec) {
+			return rec[i]
+		}
+		return ""
+	}
+
+	var rows []mod.ImportRow
+	var rowErrs []mod.ImportError
+	for {
+		rec, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			log.Debug().Err(err).Msg("can't read csv")
+			return nil, nil, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		line, _ := r.FieldPos(0)
+		if len(rows)+len(rowErrs) >= MAX_IMPORT_ROWS {
+			return nil, nil, echo.NewHTTPError(http.StatusRequestEntityTooLarge, fmt.Sprintf("no more than %d rows can be imported at once", MAX_IMPORT_ROWS))
+		}
+		regNum := field(rec, "regNum")
+		if len(rec) != len(header) {
+			rowErrs = append(rowErrs, mod.ImportError{Line: line, RegNum: regNum, Msg: fmt.Sprintf("expected %d fields, got %d", len(header), len(rec))})
+			continue
+		}
+		var year int64
+		if y := strings.TrimSpace(field(rec, "year")); len(y) > 0 {
+			if year, err = strconv.ParseInt(y, 10, 32); err != nil {
+				rowErrs = append(rowErrs, mod.ImportError{Line: line, RegNum: regNum, Msg: "year must be a number"})
+				continue
+			}
+		}
+		var engineVolume, mileage int64
+		if v := strings.TrimSpace(field(rec, "engineVolume")); len(v) > 0 {
+			if engineVolume, err = strconv.ParseInt(v, 10, 32); err != nil {
+				rowErrs = append(rowErrs, mod.ImportError{Line: line, RegNum: regNum, Msg: "engineVolume must be a number"})
+				continue
+			}
+		}
+		if m := strings.TrimSpace(field(rec, "mileage")); len(m) > 0 {
+			if mileage, err = strconv.ParseInt(m, 10, 32); err != nil {
+				rowErrs = append(rowErrs, mod.ImportError{Line: line, RegNum: regNum, Msg: "mileage must be a number"})
+				continue
+			}
+		}
+		rows = append(rows, mod.ImportRow{Line: line, Car: mod.CarDTO{
+			RegNum: regNum,
+			Mark:   field(rec, "mark"),
+			Model:  field(rec, "model"),
+			Year:   int32(year),
+			VIN:    field(rec, "vin"),
+
+			Color:        field(rec, "color"),
+			BodyType:     field(rec, "bodyType"),
+			Fuel:         field(rec, "fuel"),
+			EngineVolume: int32(engineVolume),
+			Transmission: field(rec, "transmission"),
+			Mileage:      int32(mileage),
+			Owner: &mod.PeopleDTO{
+				Name:       field(rec, "ownerName"),
+				Surname:    field(rec, "ownerSurname"),
+				Patronymic: field(rec, "ownerPatronymic"),
+			},
+		}})
+	}
+	return rows, rowErrs, nil
+}
+
+// readImportNDJSON reads one car object per line; blank lines are skipped.
+func readImportNDJSON(body io.Reader) ([]mod.ImportRow, []mod.ImportError, error) {
+	sc := bufio.NewScanner(body)
+	sc.Buffer(make([]byte, 0, 4096), MAX_NDJSON_LINE)
+
+	var rows []mod.ImportRow
+	var rowErrs []mod.ImportError
+	for line := 1; sc.Scan(); line++ {
+		data := bytes.TrimSpace(sc.Bytes())
+		if len(data) < 1 {
+			continue
+		}
+		if len(rows)+len(rowErrs) >= MAX_IMPORT_ROWS {
+			return nil, nil, echo.NewHTTPError(http.StatusRequestEntityTooLarge, fmt.Sprintf("no more than %d rows can be imported at once", MAX_IMPORT_ROWS))
+		}
+		var cJson CarJSON
+		if err := json.Unmarshal(data, &cJson); err != nil {
+			rowErrs = append(rowErrs, mod.ImportError{Line: line, Msg: "invalid JSON: " + err.Error()})
+			continue
+		}
+		car := mod.CarDTO{
+			RegNum: cJson.RegNum,
+			Mark:   cJson.Mark,
+			Model:  cJson.Model,
+			Year:   cJson.Year,
+			VIN:    cJson.Vin,
+
+			Color:        cJson.Color,
+			BodyType:     cJson.BodyType,
+			Fuel:         cJson.Fuel,
+			EngineVolume: cJson.EngineVolume,
+			Transmission: cJson.Transmission,
+			Mileage:      cJson.Mileage,
+		}
+		if cJson.Owner != nil {
+			car.Owner = &mod.PeopleDTO{
+				Name:       cJson.Owner.Name,
+				Surname:    cJson.Owner.Surname,
+				Patronymic: cJson.Owner.Patronymic,
+			}
+		}
+		rows = append(rows, mod.ImportRow{Line: line, Car: car})
+	}
+	if err := sc.Err(); err != nil {
+		log.Debug().Err(err).Msg("can't read ndjson")
+		return nil, nil, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return rows, rowErrs, nil
+}
+
+func mapImportReportToJSON(r *mod.ImportReport) ImportReportJSON {
+	res := ImportReportJSON{
+		DryRun:   r.DryRun,
+		Total:    r.Total,
+		Valid:    r.Valid,
+		Imported: len(r.Imported),
+		Skipped:  make([]string, 0, len(r.Skipped)),
+		Errors:   make([]ImportErrorJSON, 0, len(r.Errors)),
+	}
+	res.Skipped = append(res.Skipped, r.Skipped...)
+	for _, ie := range r.Errors {
+		res.Errors = append(res.Errors, ImportErrorJSON{Line: ie.Line, RegNum: ie.RegNum, Error: ie.Msg})
+	}
+	if r.Failed != nil {
+		res.Failed = &ImportFailureJSON{FromLine: r.Failed.FromLine, ToLine: r.Failed.ToLine, Error: r.Failed.Msg}
+	}
+	return res
+}
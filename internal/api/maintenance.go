@@ -0,0 +1,276 @@
+package api
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	mod "github.com/mi-raf/cars-catalog/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+const EXPAND_LAST_SERVICE = "last_service"
+
+type (
+	MaintenanceRequestJSON struct {
+		// DoneOn is the date of the event as YYYY-MM-DD
+		DoneOn string `json:"doneOn"`
+		// Mileage is in km
+		Mileage  int32  `json:"mileage,omitempty"`
+		WorkType string `json:"workType" enums:"service,oil_change,inspection,repair,tires,brakes,battery,bodywork,other"`
+		// Cost is in roubles, kopecks are kept
+		Cost  float64 `json:"cost,omitempty"`
+		Notes string  `json:"notes,omitempty"`
+	}
+
+	MaintenanceJSON struct {
+		Id        int64     `json:"id"`
+		DoneOn    string    `json:"doneOn"`
+		Mileage   int32     `json:"mileage,omitempty"`
+		WorkType  string    `json:"workType"`
+		Cost      float64   `json:"cost,omitempty"`
+		Notes     string    `json:"notes,omitempty"`
+		CreatedAt time.Time `json:"createdAt"`
+		UpdatedAt time.Time `json:"updatedAt"`
+	}
+)
+
+// @Summary Add a maintenance record.
+// @Description method to attach a service or maintenance event to the car.
+// @Accept json
+// @Produce json
+// @Success 201 {object} MaintenanceJSON
+// @Param regnum path string true "car's registration number"
+// @Param body body MaintenanceRequestJSON true "maintenance event"
+// @Header 201 {string} Location "record's URL"
+// @Failure      400  {string}  string    "error"
+// @Failure      404  {string}  string    "error"
+// @Failure      500  {string}  string    "error"
+// @Router /car/{regnum}/maintenance [post]
+func (a *API) addMaintenance(e echo.Context) error {
+	cc, err := getParentContext(e)
+	if err != nil {
+		log.Error().Err(err).Msg("can't get parent context in add maintenance")
+		return err
+	}
+
+	m, err := bindMaintenance(e)
+	if err != nil {
+		return err
+	}
+	regNum := e.Param("regnum")
+	if err = a.s.AddMaintenance(cc.Ctx, regNum, m); err != nil {
+		log.Debug().Err(err).Str("reg num", regNum).Msg("can't add maintenance")
+		return httpError(err)
+	}
+	e.Response().Header().Set(echo.HeaderLocation, carURL(regNum, "")+"/maintenance/"+strconv.FormatInt(m.ID, 10))
+	return e.JSON(http.StatusCreated, mapMaintenanceToJSON(m))
+}
+
+// @Summary Get maintenance records of a car.
+// @Description method to get the maintenance of the car, oldest first.
+// @Produce json
+// @Success 200 {array} MaintenanceJSON
+// @Param regnum path string true "car's registration number"
+// @Param from query string false "least date of the records as YYYY-MM-DD"
+// @Param to query string false "greatest date of the records as YYYY-MM-DD"
+// @Param work_type query string false "type of work" Enums(service, oil_change, inspection, repair, tires, brakes, battery, bodywork, other)
+// @Failure      400  {string}  string    "error"
+// @Failure      404  {string}  string    "error"
+// @Failure      500  {string}  string    "error"
+// @Router /car/{regnum}/maintenance [get]
+func (a *API) listMaintenance(e echo.Context) error {
+	cc, err := getParentContext(e)
+	if err != nil {
+		log.Error().Err(err).Msg("can't get parent context in list maintenance")
+		return err
+	}
+
+	from, err := parseDate(e.QueryParam("from"), "from")
+	if err != nil {
+		return err
+	}
+	to, err := parseDate(e.QueryParam("to"), "to")
+	if err != nil {
+		return err
+	}
+	filter := mod.MaintenanceFilter{From: from, To: to, WorkType: e.QueryParam("work_type")}
+
+	regNum := e.Param("regnum")
+	records, err := a.s.ListMaintenance(cc.Ctx, regNum, filter)
+	if err != nil {
+		log.Debug().Err(err).Str("reg num", regNum).Msg("can't list maintenance")
+		return httpError(err)
+	}
+	res := make([]MaintenanceJSON, 0, len(records))
+	for i := range records {
+		res = append(res, mapMaintenanceToJSON(&records[i]))
+	}
+	return e.JSON(http.StatusOK, res)
+}
+
+// @Summary Get a maintenance record.
+// @Produce json
+// @Success 200 {object} MaintenanceJSON
+// @Param regnum path string true "car's registration number"
+// @Param id path int true "record's id"
+// @Failure      400  {string}  string    "error"
+// @Failure      404  {string}  string    "error"
+// @Failure      500  {string}  string    "error"
+// @Router /car/{regnum}/maintenance/{id} [get]
+func (a *API) getMaintenance(e echo.Context) error {
+	cc, err := getParentContext(e)
+	if err != nil {
+		log.Error().Err(err).Msg("can't get parent context in get maintenance")
+		return err
+	}
+
+	id, err := maintenanceID(e)
+	if err != nil {
+		return err
+	}
+	regNum := e.Param("regnum")
+	m, err := a.s.GetMaintenance(cc.Ctx, regNum, id)
+	if err != nil {
+		log.Debug().Err(err).Str("reg num", regNum).Int64("id", id).Msg("can't get maintenance")
+		return httpError(err)
+	}
+	return e.JSON(http.StatusOK, mapMaintenanceToJSON(m))
+}
+
+// @Summary Replace a maintenance record.
+// @Accept json
+// @Produce json
+// @Success 200 {object} MaintenanceJSON
+// @Param regnum path string true "car's registration number"
+// @Param id path int true "record's id"
+// @Param body body MaintenanceRequestJSON true "maintenance event"
+// @Failure      400  {string}  string    "error"
+// @Failure      404  {string}  string    "error"
+// @Failure      500  {string}  string    "error"
+// @Router /car/{regnum}/maintenance/{id} [put]
+func (a *API) updateMaintenance(e echo.Context) error {
+	cc, err := getParentContext(e)
+	if err != nil {
+		log.Error().Err(err).Msg("can't get parent context in update maintenance")
+		return err
+	}
+
+	id, err := maintenanceID(e)
+	if err != nil {
+		return err
+	}
+	m, err := bindMaintenance(e)
+	if err != nil {
+		return err
+	}
+	m.ID = id
+	regNum := e.Param("regnum")
+	if err = a.s.UpdateMaintenance(cc.Ctx, regNum, m); err != nil {
+		log.Debug().Err(err).Str("reg num", regNum).Int64("id", id).Msg("can't update maintenance")
+		return httpError(err)
+	}
+	return e.JSON(http.StatusOK, mapMaintenanceToJSON(m))
+}
+
+// @Summary Delete a maintenance record.
+// @Success 204
+// @Param regnum path string true "car's registration number"
+// @Param id path int true "record's id"
+// @Failure      400  {string}  string    "error"
+// @Failure      404  {string}  string    "error"
+// @Failure      500  {string}  string    "error"
+// @Router /car/{regnum}/maintenance/{id} [delete]
+func (a *API) deleteMaintenance(e echo.Context) error {
+	cc, err := getParentContext(e)
+	if err != nil {
+		log.Error().Err(err).Msg("can't get parent context in delete maintenance")
+		return err
+	}
+
+	id, err := maintenanceID(e)
+	if err != nil {
+		return err
+	}
+	regNum := e.Param("regnum")
+	if err = a.s.DeleteMaintenance(cc.Ctx, regNum, id); err != nil {
+		log.Debug().Err(err).Str("reg num", regNum).Int64("id", id).Msg("can't delete maintenance")
+		return httpError(err)
+	}
+	return e.NoContent(http.StatusNoContent)
+}
+
+func bindMaintenance(e echo.Context) (*mod.Maintenance, error) {
+	reqJ := &MaintenanceRequestJSON{}
+	if err := e.Bind(reqJ); err != nil {
+		log.Debug().Err(err).Msg("can not unmarshall data")
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "Incorrect data")
+	}
+	doneOn, err := parseDate(reqJ.DoneOn, "doneOn")
+	if err != nil {
+		return nil, err
+	}
+	return &mod.Maintenance{
+		DoneOn:   doneOn,
+		Mileage:  reqJ.Mileage,
+		WorkType: reqJ.WorkType,
+		Cost:     int64(math.Round(reqJ.Cost * 100)),
+		Notes:    reqJ.Notes,
+	}, nil
+}
+
+func maintenanceID(e echo.Context) (int64, error) {
+	id, err := strconv.ParseInt(e.Param("id"), 10, 64)
+	if err != nil || id < 1 {
+		log.Debug().Err(err).Str("id", e.Param("id")).Msg("incorrect maintenance id")
+		return 0, echo.NewHTTPError(http.StatusBadRequest, "id must be a positive number")
+	}
+	return id, nil
+}
+
+// parseDate reads a YYYY-MM-DD date; an empty value is the zero time.
+func parseDate(data, name string) (time.Time, error) {
+	if len(data) < 1 {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(dateLayout, data)
+	if err != nil {
+		log.Debug().Err(err).Str("data", data).Msg("can not parse " + name)
+		return time.Time{}, echo.NewHTTPError(http.StatusBadRequest, name+" must be a date like "+dateLayout)
+	}
+	return t, nil
+}
+
+// parseExpand checks the expansions asked for in the expand query param.
+func parseExpand(e echo.Context, filter *mod.CarFilter) error {
+	data := e.QueryParam("expand")
+	if len(data) < 1 {
+		return nil
+	}
+	for _, x := range strings.Split(data, ",") {
+		switch x = strings.TrimSpace(x); x {
+		case EXPAND_LAST_SERVICE:
+			filter.LastService = true
+		default:
+			log.Debug().Str("expand", x).Msg("unknown expansion")
+			return echo.NewHTTPError(http.StatusBadRequest, "unknown expand: "+x)
+		}
+	}
+	return nil
+}
+
+func mapMaintenanceToJSON(m *mod.Maintenance) MaintenanceJSON {
+	return MaintenanceJSON{
+		Id:        m.ID,
+		DoneOn:    m.DoneOn.Format(dateLayout),
+		Mileage:   m.Mileage,
+		WorkType:  m.WorkType,
+		Cost:      float64(m.Cost) / 100,
+		Notes:     m.Notes,
+		CreatedAt: m.CreatedAt,
+		UpdatedAt: m.UpdatedAt,
+	}
+}
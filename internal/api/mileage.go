@@ -0,0 +1,108 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	mod "github.com/mi-raf/cars-catalog/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+const dateLayout = "2006-01-02"
+
+type (
+	MileageRequestJSON struct {
+		// Mileage is in km
+		Mileage int32 `json:"mileage"`
+		// ReadOn is the date of the reading as YYYY-MM-DD, today when empty
+		ReadOn string `json:"readOn,omitempty"`
+		// Override logs a reading lower than an earlier one and marks the car
+		Override bool `json:"override,omitempty"`
+	}
+
+	MileageReadingJSON struct {
+		Mileage   int32     `json:"mileage"`
+		ReadOn    string    `json:"readOn"`
+		Rollback  bool      `json:"rollback,omitempty"`
+		CreatedAt time.Time `json:"createdAt"`
+	}
+)
+
+// @Summary Log an odometer reading.
+// @Description method to add a mileage reading of the car. Readings must not decrease by date; a reading out of order is refused with 409 unless override is set, then it is logged as a rollback and the car gets suspiciousRollback.
+// @Accept json
+// @Produce json
+// @Success 201
+// @Param regnum path string true "car's registration number"
+// @Param body body MileageRequestJSON true "odometer reading"
+// @Failure      400  {string}  string    "error"
+// @Failure      404  {string}  string    "error"
+// @Failure      409  {string}  string    "error"
+// @Failure      500  {string}  string    "error"
+// @Router /car/{regnum}/mileage [post]
+func (a *API) addCarMileage(e echo.Context) error {
+	cc, err := getParentContext(e)
+	if err != nil {
+		log.Error().Err(err).Msg("can't get parent context in mileage")
+		return err
+	}
+
+	reqJ := &MileageRequestJSON{}
+	if err = e.Bind(reqJ); err != nil {
+		log.Debug().Err(err).Msg("can not unmarshall data")
+		return echo.NewHTTPError(http.StatusBadRequest, "Incorrect data")
+	}
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	readOn, err := parseDate(reqJ.ReadOn, "readOn")
+	if err != nil {
+		return err
+	}
+	if readOn.IsZero() {
+		readOn = today
+	}
+	if readOn.After(today) {
+		return echo.NewHTTPError(http.StatusBadRequest, "readOn must not be in the future")
+	}
+
+	regNum := e.Param("regnum")
+	reading := mod.MileageReading{Mileage: reqJ.Mileage, ReadOn: readOn}
+	if err = a.s.AddMileage(cc.Ctx, regNum, reading, reqJ.Override); err != nil {
+		log.Debug().Err(err).Str("reg num", regNum).Msg("can't add mileage")
+		return httpError(err)
+	}
+	return e.NoContent(http.StatusCreated)
+}
+
+// @Summary Get odometer readings of a car.
+// @Description method to get the mileage log of the car, oldest reading first.
+// @Produce json
+// @Success 200 {array} MileageReadingJSON
+// @Param regnum path string true "car's registration number"
+// @Failure      404  {string}  string    "error"
+// @Failure      500  {string}  string    "error"
+// @Router /car/{regnum}/mileage [get]
+func (a *API) getCarMileage(e echo.Context) error {
+	cc, err := getParentContext(e)
+	if err != nil {
+		log.Error().Err(err).Msg("can't get parent context in mileage")
+		return err
+	}
+
+	regNum := e.Param("regnum")
+	readings, err := a.s.Mileage(cc.Ctx, regNum)
+	if err != nil {
+		log.Debug().Err(err).Str("reg num", regNum).Msg("can't get mileage")
+		return httpError(err)
+	}
+	res := make([]MileageReadingJSON, 0, len(readings))
+	for _, m := range readings {
+		res = append(res, MileageReadingJSON{
+			Mileage:   m.Mileage,
+			ReadOn:    m.ReadOn.Format(dateLayout),
+			Rollback:  m.Rollback,
+			CreatedAt: m.CreatedAt,
+		})
+	}
+	return e.JSON(http.StatusOK, res)
+}
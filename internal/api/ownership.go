@@ -0,0 +1,108 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	mod "github.com/mi-raf/cars-catalog/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+type (
+	TransferRequestJSON struct {
+		Owner *PeopleJSON `json:"owner"`
+	}
+
+	OwnershipJSON struct {
+		Owner     PeopleJSON `json:"owner"`
+		ValidFrom time.Time  `json:"validFrom"`
+		ValidTo   *time.Time `json:"validTo,omitempty"`
+	}
+)
+
+// @Summary Transfer car to a new owner.
+// @Description method to close the current ownership of the car and open a new one atomically.
+// @Accept json
+// @Produce json
+// @Success 200
+// @Param regnum path string true "car's registration number"
+// @Param body body TransferRequestJSON true "new owner"
+// @Param If-Match header string false "car's ETag from GET /car/{regnum}"
+// @Failure      400  {string}  string    "error"
+// @Failure      404  {string}  string    "error"
+// @Failure      409  {string}  string    "error"
+// @Failure      412  {string}  string    "error"
+// @Failure      428  {string}  string    "error"
+// @Failure      500  {string}  string    "error"
+// @Router /car/{regnum}/transfer [post]
+func (a *API) transferCar(e echo.Context) error {
+	cc, err := getParentContext(e)
+	if err != nil {
+		log.Error().Err(err).Msg("can't get parent context in transfer")
+		return err
+	}
+
+	reqJ := &TransferRequestJSON{}
+	if err = e.Bind(reqJ); err != nil || reqJ.Owner == nil {
+		log.Debug().Err(err).Msg("can not unmarshall data")
+		return echo.NewHTTPError(http.StatusBadRequest, "Incorrect data")
+	}
+	version, err := ifMatchVersion(e, a.requireIfMatch)
+	if err != nil {
+		return err
+	}
+
+	regNum := e.Param("regnum")
+	owner := mod.PeopleDTO{
+		Name:       reqJ.Owner.Name,
+		Surname:    reqJ.Owner.Surname,
+		Patronymic: reqJ.Owner.Patronymic,
+	}
+	err = a.s.Transfer(cc.Ctx, regNum, &owner, version)
+	if err != nil {
+		log.Debug().Err(err).Str("reg num", regNum).Msg("can't transfer car")
+		return httpError(err)
+	}
+	log.Debug().Str("reg num", regNum).Msg("transfer car")
+	return e.NoContent(http.StatusOK)
+}
+
+// @Summary Get owners of a car.
+// @Description method to get the full chain of the car's owners, oldest first. The current owner has no validTo.
+// @Produce json
+// @Success 200 {array} OwnershipJSON
+// @Param regnum path string true "car's registration number"
+// @Failure      404  {string}  string    "error"
+// @Failure      500  {string}  string    "error"
+// @Router /car/{regnum}/owners [get]
+func (a *API) getCarOwners(e echo.Context) error {
+	cc, err := getParentContext(e)
+	if err != nil {
+		log.Error().Err(err).Msg("can't get parent context in owners")
+		return err
+	}
+
+	regNum := e.Param("regnum")
+	owners, err := a.s.Owners(cc.Ctx, regNum)
+	if err != nil {
+		log.Debug().Err(err).Str("reg num", regNum).Msg("can't get owners")
+		return httpError(err)
+	}
+	res := make([]OwnershipJSON, 0, len(owners))
+	for _, o := range owners {
+		oJ := OwnershipJSON{
+			Owner: PeopleJSON{
+				Name:       o.Owner.Name,
+				Surname:    o.Owner.Surname,
+				Patronymic: o.Owner.Patronymic,
+			},
+			ValidFrom: o.ValidFrom,
+		}
+		if !o.ValidTo.IsZero() {
+			oJ.ValidTo = &o.ValidTo
+		}
+		res = append(res, oJ)
+	}
+	return e.JSON(http.StatusOK, res)
+}
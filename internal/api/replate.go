@@ -0,0 +1,123 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/mi-raf/cars-catalog/internal"
+	"github.com/rs/zerolog/log"
+)
+
+type (
+	ReplateRequestJSON struct {
+		RegNum string `json:"regNum"`
+	}
+
+	PlateChangeJSON struct {
+		OldRegNum string    `json:"oldRegNum"`
+		NewRegNum string    `json:"newRegNum"`
+		ChangedAt time.Time `json:"changedAt"`
+	}
+)
+
+// @Summary Give a car new plates.
+// @Description method to change the car's registration number keeping its id, owners and history. Afterwards a lookup of the old plate is redirected to the car and the car's sub-resources can be read by the old plate; writes need the current plate and give 404 for the old one.
+// @Accept json
+// @Produce json
+// @Success 200
+// @Param regnum path string true "car's registration number"
+// @Param body body ReplateRequestJSON true "new registration number"
+// @Param If-Match header string false "car's ETag from GET /car/{regnum}"
+// @Header 200 {string} Location "car's new URL"
+// @Failure      400  {string}  string    "error"
+// @Failure      404  {string}  string    "error"
+// @Failure      409  {string}  string    "error"
+// @Failure      412  {string}  string    "error"
+// @Failure      428  {string}  string    "error"
+// @Failure      500  {string}  string    "error"
+// @Router /car/{regnum}/replate [post]
+func (a *API) replateCar(e echo.Context) error {
+	cc, err := getParentContext(e)
+	if err != nil {
+		log.Error().Err(err).Msg("can't get parent context in replate")
+		return err
+	}
+
+	reqJ := &ReplateRequestJSON{}
+	if err = e.Bind(reqJ); err != nil || len(reqJ.RegNum) < 1 {
+		log.Debug().Err(err).Msg("can not unmarshall data")
+		return echo.NewHTTPError(http.StatusBadRequest, "Incorrect data")
+	}
+	version, err := ifMatchVersion(e, a.requireIfMatch)
+	if err != nil {
+		return err
+	}
+
+	regNum := e.Param("regnum")
+	if err = a.s.Replate(cc.Ctx, regNum, reqJ.RegNum, version); err != nil {
+		log.Debug().Err(err).Str("reg num", regNum).Msg("can't replate car")
+		return httpError(err)
+	}
+	car, err := a.s.Get(cc.Ctx, reqJ.RegNum, time.Time{})
+	if err != nil {
+		log.Error().Err(err).Str("reg num", reqJ.RegNum).Msg("can't get replated car")
+		return httpError(err)
+	}
+	e.Response().Header().Set(echo.HeaderLocation, carURL(car.RegNum, ""))
+	return e.NoContent(http.StatusOK)
+}
+
+// @Summary Get plate history of a car.
+// @Description method to get every plate change of the car, oldest first.
+// @Produce json
+// @Success 200 {array} PlateChangeJSON
+// @Param regnum path string true "car's registration number, a former one is accepted"
+// @Failure      500  {string}  string    "error"
+// @Router /car/{regnum}/plates [get]
+func (a *API) getCarPlates(e echo.Context) error {
+	cc, err := getParentContext(e)
+	if err != nil {
+		log.Error().Err(err).Msg("can't get parent context in plates")
+		return err
+	}
+
+	regNum := e.Param("regnum")
+	changes, err := a.s.Plates(cc.Ctx, regNum)
+	if err != nil {
+		log.Error().Err(err).Str("reg num", regNum).Msg("can't get plates")
+		return httpError(err)
+	}
+	res := make([]PlateChangeJSON, 0, len(changes))
+	for _, pc := range changes {
+		res = append(res, PlateChangeJSON{OldRegNum: pc.OldRegNum, NewRegNum: pc.NewRegNum, ChangedAt: pc.ChangedAt})
+	}
+	return e.JSON(http.StatusOK, res)
+}
+
+// redirectReplated answers a lookup of a plate the car no longer has with a
+// redirect to the car, or with err when nobody had the plate.
+func (a *API) redirectReplated(e echo.Context, cc *Context, regNum string, err error) error {
+	if !errors.Is(err, internal.ErrNotFound) {
+		return httpError(err)
+	}
+	current, cerr := a.s.CurrentPlate(cc.Ctx, regNum)
+	if cerr != nil {
+		if !errors.Is(cerr, internal.ErrNotFound) {
+			log.Error().Err(cerr).Str("reg num", regNum).Msg("can't find current plate")
+		}
+		return httpError(err)
+	}
+	log.Debug().Str("reg num", regNum).Str("current", current).Msg("redirect to current plate")
+	return e.Redirect(http.StatusFound, carURL(current, e.QueryString()))
+}
+
+func carURL(regNum, query string) string {
+	u := "/car/" + url.PathEscape(regNum)
+	if len(query) > 0 {
+		u += "?" + query
+	}
+	return u
+}
@@ -0,0 +1,172 @@
+package api
+
+import (
+	"sort"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	mod "github.com/mi-raf/cars-catalog/internal/models"
+	"github.com/mi-raf/cars-catalog/internal/xlsx"
+	"github.com/rs/zerolog/log"
+)
+
+const mimeXLSX = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+type (
+	reportOwner struct {
+		name, surname, patronymic string
+	}
+
+	reportMarkYear struct {
+		mark string
+		year int32
+	}
+)
+
+// @Summary Catalog report as Excel workbook.
+// @Description method to build an XLSX workbook with the cars matching the filter, their owners and a summary by mark and year.
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Success 200 {file} file
+// @Param year query int false "car's filter param year"
+// @Param reg_num query string false "car's filter param registration number"
+// @Param mark query string false "car's filter param mark"
+// @Param model query string false "car's filter param model"
+// @Param name query string false "car's filter param owner's name"
+// @Param surname query string false "car's filter param owner's surname"
+// @Param patronymic query string false "car's filter param owner's patronymic"
+// @Param region query string false "car's filter param region code of the plate"
+// @Param vin query string false "car's filter param VIN"
+// @Param color query string false "car's filter param color, case insensitive"
+// @Param body_type query string false "car's filter param body type" Enums(sedan, hatchback, wagon, coupe, convertible, suv, crossover, minivan, pickup, van, bus, truck, motorcycle)
+// @Param fuel query string false "car's filter param fuel" Enums(petrol, diesel, lpg, cng, hybrid, electric)
+// @Param transmission query string false "car's filter param transmission" Enums(manual, automatic, robot, cvt)
+// @Param mileage_min query int false "car's filter param least mileage in km"
+// @Param mileage_max query int false "car's filter param greatest mileage in km"
+// @Param engine_volume_min query int false "car's filter param least engine volume in cm3"
+// @Param engine_volume_max query int false "car's filter param greatest engine volume in cm3"
+// @Param status query string false "car's filter param lifecycle status" Enums(active, sold, stolen, scrapped, exported)
+// @Param include_deleted query bool false "include soft deleted cars"
+// @Param as_of query string false "RFC 3339 time to get the catalog as it was at that instant"
+// @Failure      400  {string}  string    "error"
+// @Router /reports/catalog.xlsx [get]
+func (a *API) getCatalogReport(e echo.Context) error {
+	filter, err := parseCarFilter(e)
+	if err != nil {
+		return err
+	}
+
+	// the cars sheet is streamed while owners and summary are aggregated for
+	// the sheets written after it; the workbook is buffered until the first
+	// rows, so a failed query still gets its own status
+	res := e.Response()
+	w := xlsx.NewWriter(newStreamWriter(res, map[string]string{
+		echo.HeaderContentType:        mimeXLSX,
+		echo.HeaderContentDisposition: `attachment; filename="catalog.xlsx"`,
+	}))
+	cars, err := w.NewSheet("Cars", xlsx.SheetOptions{Widths: []float64{14, 16, 18, 8, 14, 18, 18, 22}, FreezeHeader: true})
+	if err != nil {
+		log.Error().Err(err).Msg("can't create cars sheet")
+		return nil
+	}
+	if err = cars.Header("Reg num", "Mark", "Model", "Year", "Owner name", "Owner surname", "Owner patronymic", "Deleted at"); err != nil {
+		log.Error().Err(err).Msg("can't write report")
+		return nil
+	}
+
+	owners := map[reportOwner]int{}
+	summary := map[reportMarkYear]int{}
+	rows := 0
+	err = a.s.ForEach(e.Request().Context(), filter, func(c *mod.CarDTO) error {
+		rows++
+		row := []xlsx.Cell{xlsx.String(c.RegNum), xlsx.String(c.Mark), xlsx.String(c.Model), {}, {}, {}, {}, {}}
+		if c.Year > 0 {
+			row[3] = xlsx.Int(int64(c.Year))
+		}
+		if c.Owner != nil {
+			row[4], row[5], row[6] = xlsx.String(c.Owner.Name), xlsx.String(c.Owner.Surname), xlsx.String(c.Owner.Patronymic)
+			owners[reportOwner{c.Owner.Name, c.Owner.Surname, c.Owner.Patronymic}]++
+		}
+		if !c.DeletedAt.IsZero() {
+			row[7] = xlsx.String(c.DeletedAt.UTC().Format(time.RFC3339))
+		}
+		summary[reportMarkYear{c.Mark, c.Year}]++
+		return cars.WriteRow(row...)
+	})
+	if err != nil {
+		return exportError(res, err, rows, "xlsx")
+	}
+	if err = writeOwnersSheet(w, owners); err != nil {
+		log.Error().Err(err).Msg("can't write owners sheet")
+		return nil
+	}
+	if err = writeSummarySheet(w, summary); err != nil {
+		log.Error().Err(err).Msg("can't write summary sheet")
+		return nil
+	}
+	if err = w.Close(); err != nil {
+		log.Error().Err(err).Msg("can't close report")
+		return nil
+	}
+	log.Debug().Interface("filter", filter).Int("rows", rows).Msg("build catalog report")
+	return nil
+}
+
+func writeOwnersSheet(w *xlsx.Writer, owners map[reportOwner]int) error {
+	s, err := w.NewSheet("Owners", xlsx.SheetOptions{Widths: []float64{14, 18, 18, 8}, FreezeHeader: true})
+	if err != nil {
+		return err
+	}
+	if err = s.Header("Name", "Surname", "Patronymic", "Cars"); err != nil {
+		return err
+	}
+	keys := make([]reportOwner, 0, len(owners))
+	for o := range owners {
+		keys = append(keys, o)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].surname != keys[j].surname {
+			return keys[i].surname < keys[j].surname
+		}
+		if keys[i].name != keys[j].name {
+			return keys[i].name < keys[j].name
+		}
+		return keys[i].patronymic < keys[j].patronymic
+	})
+	for _, o := range keys {
+		err = s.WriteRow(xlsx.String(o.name), xlsx.String(o.surname), xlsx.String(o.patronymic), xlsx.Int(int64(owners[o])))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeSummarySheet(w *xlsx.Writer, summary map[reportMarkYear]int) error {
+	s, err := w.NewSheet("Summary", xlsx.SheetOptions{Widths: []float64{16, 8, 8}, FreezeHeader: true})
+	if err != nil {
+		return err
+	}
+	if err = s.Header("Mark", "Year", "Cars"); err != nil {
+		return err
+	}
+	keys := make([]reportMarkYear, 0, len(summary))
+	for k := range summary {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].mark != keys[j].mark {
+			return keys[i].mark < keys[j].mark
+		}
+		return keys[i].year < keys[j].year
+	})
+	for _, k := range keys {
+		year := xlsx.Cell{}
+		if k.year > 0 {
+			year = xlsx.Int(int64(k.year))
+		}
+		if err = s.WriteRow(xlsx.String(k.mark), year, xlsx.Int(int64(summary[k]))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+const MAX_SEARCH_QUERY = 200
+
+type SearchHitJSON struct {
+	Car  CarJSON `json:"car"`
+	Rank float64 `json:"rank"`
+	// Highlight has the matched words wrapped in <mark> tags.
+	Highlight string `json:"highlight"`
+}
+
+// @Summary Search cars by free text.
+// @Description method to find active cars by words of registration number, mark, model and owner's names. Words match as prefixes and misspellings are tolerated; the most relevant cars come first.
+// @Produce json
+// @Success 200 {array} SearchHitJSON
+// @Param q query string true "search text"
+// @Param limit query int false "limit of responce size"
+// @Param offset query int false "offset of responce for database"
+// @Failure      400  {string}  string    "error"
+// @Failure      500  {string}  string    "error"
+// @Router /car/search [get]
+func (a *API) searchCars(e echo.Context) error {
+	cc, err := getParentContext(e)
+	if err != nil {
+		log.Error().Err(err).Msg("can't get parent context in search")
+		return err
+	}
+
+	q := e.QueryParam("q")
+	if len([]rune(q)) > MAX_SEARCH_QUERY {
+		return echo.NewHTTPError(http.StatusBadRequest, "q is too long")
+	}
+	limit, err := safeAtoi(e.QueryParam("limit"), func(i int) bool { return i > 0 })
+	if err != nil {
+		log.Debug().Err(err).Msg("incorrect limit")
+		return err
+	}
+	limit = min(limit, MAX_LIMIT)
+	limit = max(limit, MIN_LIMIT)
+	offset, err := safeAtoi(e.QueryParam("offset"), func(i int) bool { return i >= 0 })
+	if err != nil {
+		log.Debug().Err(err).Msg("incorrect offset")
+		return err
+	}
+
+	hits, err := a.s.Search(cc.Ctx, q, offset, limit)
+	if err != nil {
+		log.Error().Err(err).Str("query", q).Msg("can't search cars")
+		return httpError(err)
+	}
+	res := make([]SearchHitJSON, 0, len(hits))
+	for _, h := range hits {
+		res = append(res, SearchHitJSON{Car: mapCarToJSON(&h.Car), Rank: h.Rank, Highlight: h.Highlight})
+	}
+	return e.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,110 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	mod "github.com/mi-raf/cars-catalog/internal/models"
+	"github.com/mi-raf/cars-catalog/internal/plate"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	DEFAULT_STATS_TOP   = 10
+	MAX_STATS_TOP       = 100
+	DEFAULT_YEAR_BUCKET = 10
+	MAX_YEAR_BUCKET     = 100
+)
+
+type (
+	StatGroupJSON struct {
+		Key string `json:"key"`
+		// Name is the region name when grouping by region
+		Name  string `json:"name,omitempty"`
+		Count int64  `json:"count"`
+	}
+
+	StatsJSON struct {
+		GroupBy string          `json:"groupBy"`
+		Total   int64           `json:"total"`
+		Groups  []StatGroupJSON `json:"groups"`
+	}
+)
+
+// @Summary Car statistics.
+// @Description method to count cars matching the filter grouped by mark, model, year bucket, owner or plate region. Only the top groups by count are returned; total counts all matching cars.
+// @Produce json
+// @Success 200 {object} StatsJSON
+// @Param group_by query string true "dimension to group by" Enums(mark, model, year, owner, region)
+// @Param top query int false "number of the largest groups to return"
+// @Param year_bucket query int false "width of a year group in years"
+// @Param year query int false "car's filter param year"
+// @Param reg_num query string false "car's filter param registration number"
+// @Param mark query string false "car's filter param mark"
+// @Param model query string false "car's filter param model"
+// @Param name query string false "car's filter param owner's name"
+// @Param surname query string false "car's filter param owner's surname"
+// @Param patronymic query string false "car's filter param owner's patronymic"
+// @Param region query string false "car's filter param region code of the plate"
+// @Param vin query string false "car's filter param VIN"
+// @Param color query string false "car's filter param color, case insensitive"
+// @Param body_type query string false "car's filter param body type" Enums(sedan, hatchback, wagon, coupe, convertible, suv, crossover, minivan, pickup, van, bus, truck, motorcycle)
+// @Param fuel query string false "car's filter param fuel" Enums(petrol, diesel, lpg, cng, hybrid, electric)
+// @Param transmission query string false "car's filter param transmission" Enums(manual, automatic, robot, cvt)
+// @Param mileage_min query int false "car's filter param least mileage in km"
+// @Param mileage_max query int false "car's filter param greatest mileage in km"
+// @Param engine_volume_min query int false "car's filter param least engine volume in cm3"
+// @Param engine_volume_max query int false "car's filter param greatest engine volume in cm3"
+// @Param status query string false "car's filter param lifecycle status" Enums(active, sold, stolen, scrapped, exported)
+// @Param include_deleted query bool false "include soft deleted cars"
+// @Param as_of query string false "RFC 3339 time to get the catalog as it was at that instant"
+// @Failure      400  {string}  string    "error"
+// @Failure      500  {string}  string    "error"
+// @Router /car/stats [get]
+func (a *API) getStats(e echo.Context) error {
+	cc, err := getParentContext(e)
+	if err != nil {
+		log.Error().Err(err).Msg("can't get parent context in stats")
+		return err
+	}
+
+	groupBy := e.QueryParam("group_by")
+	if len(groupBy) < 1 {
+		return echo.NewHTTPError(http.StatusBadRequest, "group_by is required")
+	}
+	top, err := safeAtoi(e.QueryParam("top"), func(i int) bool { return i > 0 && i <= MAX_STATS_TOP })
+	if err != nil {
+		log.Debug().Err(err).Msg("incorrect top")
+		return err
+	}
+	if top == 0 {
+		top = DEFAULT_STATS_TOP
+	}
+	bucket, err := safeAtoi(e.QueryParam("year_bucket"), func(i int) bool { return i > 0 && i <= MAX_YEAR_BUCKET })
+	if err != nil {
+		log.Debug().Err(err).Msg("incorrect year_bucket")
+		return err
+	}
+	if bucket == 0 {
+		bucket = DEFAULT_YEAR_BUCKET
+	}
+	filter, err := parseCarFilter(e)
+	if err != nil {
+		return err
+	}
+
+	stats, err := a.s.Stats(cc.Ctx, filter, mod.StatsQuery{GroupBy: groupBy, YearBucket: int32(bucket), Top: top})
+	if err != nil {
+		log.Error().Err(err).Str("group by", groupBy).Msg("can't get stats")
+		return httpError(err)
+	}
+	res := StatsJSON{GroupBy: stats.GroupBy, Total: stats.Total, Groups: make([]StatGroupJSON, 0, len(stats.Groups))}
+	for _, g := range stats.Groups {
+		gj := StatGroupJSON{Key: g.Key, Count: g.Count}
+		if stats.GroupBy == mod.StatsByRegion {
+			gj.Name = plate.RegionName(g.Key)
+		}
+		res.Groups = append(res.Groups, gj)
+	}
+	return e.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+type StatusRequestJSON struct {
+	Status string `json:"status" enums:"active,sold,stolen,scrapped,exported"`
+	Reason string `json:"reason"`
+}
+
+// @Summary Change lifecycle status of a car.
+// @Description method to move the car to another status with a reason. Allowed transitions: active to sold, stolen, scrapped or exported; sold to active, stolen, scrapped or exported; stolen to active or scrapped; exported to active. Scrapped is terminal. Scrapped and exported cars can not be updated, transferred, re-plated or given odometer readings; stolen cars can not be transferred or re-plated. Such writes are refused with 409.
+// @Accept json
+// @Produce json
+// @Success 200
+// @Param regnum path string true "car's registration number"
+// @Param body body StatusRequestJSON true "new status and its reason"
+// @Param If-Match header string false "car's ETag from GET /car/{regnum}"
+// @Failure      400  {string}  string    "error"
+// @Failure      404  {string}  string    "error"
+// @Failure      409  {string}  string    "error"
+// @Failure      412  {string}  string    "error"
+// @Failure      428  {string}  string    "error"
+// @Failure      500  {string}  string    "error"
+// @Router /car/{regnum}/status [post]
+func (a *API) changeCarStatus(e echo.Context) error {
+	cc, err := getParentContext(e)
+	if err != nil {
+		log.Error().Err(err).Msg("can't get parent context in status")
+		return err
+	}
+
+	reqJ := &StatusRequestJSON{}
+	if err = e.Bind(reqJ); err != nil {
+		log.Debug().Err(err).Msg("can not unmarshall data")
+		return echo.NewHTTPError(http.StatusBadRequest, "Incorrect data")
+	}
+	version, err := ifMatchVersion(e, a.requireIfMatch)
+	if err != nil {
+		return err
+	}
+
+	regNum := e.Param("regnum")
+	if err = a.s.ChangeStatus(cc.Ctx, regNum, reqJ.Status, reqJ.Reason, version); err != nil {
+		log.Debug().Err(err).Str("reg num", regNum).Msg("can't change status")
+		return httpError(err)
+	}
+	log.Debug().Str("reg num", regNum).Str("status", reqJ.Status).Msg("change status")
+	return e.NoContent(http.StatusOK)
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	mod "github.com/mi-raf/cars-catalog/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	DEFAULT_SUGGEST_LIMIT = 10
+	MAX_SUGGEST_LIMIT     = 50
+	MAX_SUGGEST_QUERY     = 60
+)
+
+type SuggestionJSON struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// @Summary Suggest car marks.
+// @Description method to autocomplete marks by case insensitive prefix, the most common first.
+// @Produce json
+// @Success 200 {array} SuggestionJSON
+// @Param q query string false "prefix of the mark"
+// @Param limit query int false "max number of suggestions"
+// @Failure      400  {string}  string    "error"
+// @Failure      500  {string}  string    "error"
+// @Router /suggest/mark [get]
+func (a *API) suggestMark(e echo.Context) error {
+	cc, q, limit, err := parseSuggest(e)
+	if err != nil {
+		return err
+	}
+	res, err := a.s.SuggestMarks(cc.Ctx, q, limit)
+	return suggestions(e, res, err)
+}
+
+// @Summary Suggest car models.
+// @Description method to autocomplete models by case insensitive prefix, optionally of one mark, the most common first.
+// @Produce json
+// @Success 200 {array} SuggestionJSON
+// @Param q query string false "prefix of the model"
+// @Param mark query string false "mark the models belong to"
+// @Param limit query int false "max number of suggestions"
+// @Failure      400  {string}  string    "error"
+// @Failure      500  {string}  string    "error"
+// @Router /suggest/model [get]
+func (a *API) suggestModel(e echo.Context) error {
+	cc, q, limit, err := parseSuggest(e)
+	if err != nil {
+		return err
+	}
+	res, err := a.s.SuggestModels(cc.Ctx, e.QueryParam("mark"), q, limit)
+	return suggestions(e, res, err)
+}
+
+// @Summary Suggest owners.
+// @Description method to autocomplete owners by case insensitive prefix of "surname name" or "name surname", owners of more cars first.
+// @Produce json
+// @Success 200 {array} SuggestionJSON
+// @Param q query string false "prefix of the owner's full name"
+// @Param limit query int false "max number of suggestions"
+// @Failure      400  {string}  string    "error"
+// @Failure      500  {string}  string    "error"
+// @Router /suggest/owner [get]
+func (a *API) suggestOwner(e echo.Context) error {
+	cc, q, limit, err := parseSuggest(e)
+	if err != nil {
+		return err
+	}
+	res, err := a.s.SuggestOwners(cc.Ctx, q, limit)
+	return suggestions(e, res, err)
+}
+
+func parseSuggest(e echo.Context) (*Context, string, int, error) {
+	cc, err := getParentContext(e)
+	if err != nil {
+		log.Error().Err(err).Msg("can't get parent context in suggest")
+		return nil, "", 0, err
+	}
+	q := e.QueryParam("q")
+	if len([]rune(q)) > MAX_SUGGEST_QUERY {
+		return nil, "", 0, echo.NewHTTPError(http.StatusBadRequest, "q is too long")
+	}
+	limit, err := safeAtoi(e.QueryParam("limit"), func(i int) bool { return i > 0 && i <= MAX_SUGGEST_LIMIT })
+	if err != nil {
+		log.Debug().Err(err).Msg("incorrect limit")
+		return nil, "", 0, err
+	}
+	if limit == 0 {
+		limit = DEFAULT_SUGGEST_LIMIT
+	}
+	return cc, q, limit, nil
+}
+
+func suggestions(e echo.Context, res []mod.StatGroup, err error) error {
+	if err != nil {
+		log.Error().Err(err).Msg("can't get suggestions")
+		return echo.ErrInternalServerError
+	}
+	resJ := make([]SuggestionJSON, 0, len(res))
+	for _, s := range res {
+		resJ = append(resJ, SuggestionJSON{Value: s.Key, Count: s.Count})
+	}
+	return e.JSON(http.StatusOK, resJ)
+}
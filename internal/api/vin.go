@@ -0,0 +1,62 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	mod "github.com/mi-raf/cars-catalog/internal/models"
+	"github.com/mi-raf/cars-catalog/internal/vin"
+	"github.com/rs/zerolog/log"
+)
+
+type (
+	VINInfoJSON struct {
+		Vin       string `json:"vin"`
+		Wmi       string `json:"wmi"`
+		Mark      string `json:"mark,omitempty"`
+		Country   string `json:"country,omitempty"`
+		ModelYear int    `json:"modelYear,omitempty"`
+	}
+
+	VINMismatchJSON struct {
+		RegNum      string `json:"regNum"`
+		Vin         string `json:"vin"`
+		Mark        string `json:"mark"`
+		DecodedMark string `json:"decodedMark"`
+	}
+
+	AddCarsResponseJSON struct {
+		VinMismatches []VINMismatchJSON `json:"vinMismatches"`
+	}
+)
+
+// @Summary Decode VIN.
+// @Description method to tell the manufacturer and model year of a VIN offline, from the embedded WMI table. Mark and country are omitted for unknown manufacturers.
+// @Produce json
+// @Success 200 {object} VINInfoJSON
+// @Param vin path string true "vehicle identification number"
+// @Failure      400  {string}  string    "error"
+// @Router /vin/{vin}/decode [get]
+func decodeVIN(e echo.Context) error {
+	v := vin.Normalize(e.Param("vin"))
+	info, err := vin.Decode(v)
+	if err != nil {
+		log.Debug().Err(err).Msg("can't decode vin")
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid VIN")
+	}
+	return e.JSON(http.StatusOK, VINInfoJSON{
+		Vin:       v,
+		Wmi:       info.WMI,
+		Mark:      info.Mark,
+		Country:   info.Country,
+		ModelYear: info.ModelYear,
+	})
+}
+
+func mapVINMismatchesToJSON(ms []mod.VINMismatch) AddCarsResponseJSON {
+	res := AddCarsResponseJSON{VinMismatches: make([]VINMismatchJSON, 0, len(ms))}
+	for _, m := range ms {
+		res.VinMismatches = append(res.VinMismatches, VINMismatchJSON{RegNum: m.RegNum, Vin: m.VIN, Mark: m.Mark, DecodedMark: m.DecodedMark})
+	}
+	return res
+}
@@ -0,0 +1,33 @@
+// Package audit carries the author of a change through the request context so
+// that the repository can record who made every mutation.
+package audit
+
+import "context"
+
+const (
+	// SystemActor is recorded for changes made by background jobs.
+	SystemActor = "system"
+)
+
+type (
+	Meta struct {
+		Actor     string
+		RequestID string
+	}
+
+	metaKey struct{}
+)
+
+func WithMeta(ctx context.Context, m Meta) context.Context {
+	return context.WithValue(ctx, metaKey{}, m)
+}
+
+// FromContext returns the change author stored in ctx. Changes without a
+// request are attributed to SystemActor.
+func FromContext(ctx context.Context) Meta {
+	m, ok := ctx.Value(metaKey{}).(Meta)
+	if !ok || len(m.Actor) < 1 {
+		m.Actor = SystemActor
+	}
+	return m
+}
@@ -0,0 +1,118 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+type (
+	// LRU is a size bounded cache safe for concurrent use. The least recently
+	// used entry is evicted when the cache is full. Entries older than ttl are
+	// treated as missing when ttl is positive.
+	LRU[K comparable, V any] struct {
+		mu    sync.Mutex
+		size  int
+		ttl   time.Duration
+		ll    *list.List
+		items map[K]*list.Element
+		// gen counts purges, see AddIf
+		gen uint64
+	}
+
+	entry[K comparable, V any] struct {
+		key     K
+		value   V
+		created time.Time
+	}
+)
+
+func New[K comparable, V any](size int, ttl time.Duration) *LRU[K, V] {
+	return &LRU[K, V]{
+		size:  size,
+		ttl:   ttl,
+		ll:    list.New(),
+		items: make(map[K]*list.Element, size),
+	}
+}
+
+func (c *LRU[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var zero V
+	el, ok := c.items[key]
+	if !ok {
+		return zero, false
+	}
+	en := el.Value.(*entry[K, V])
+	if c.ttl > 0 && time.Since(en.created) > c.ttl {
+		c.removeElement(el)
+		return zero, false
+	}
+	c.ll.MoveToFront(el)
+	return en.value, true
+}
+
+func (c *LRU[K, V]) Add(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.add(key, value)
+}
+
+// Generation returns the number of purges so far. A value computed from data
+// read after taking the generation is stored with AddIf.
+func (c *LRU[K, V]) Generation() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.gen
+}
+
+// AddIf adds the value unless the cache was purged since gen was taken, in
+// which case the value may be stale and is dropped. It reports whether the
+// value was added.
+func (c *LRU[K, V]) AddIf(gen uint64, key K, value V) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if gen != c.gen {
+		return false
+	}
+	c.add(key, value)
+	return true
+}
+
+func (c *LRU[K, V]) add(key K, value V) {
+	if el, ok := c.items[key]; ok {
+		en := el.Value.(*entry[K, V])
+		en.value = value
+		en.created = time.Now()
+		c.ll.MoveToFront(el)
+		return
+	}
+	c.items[key] = c.ll.PushFront(&entry[K, V]{key: key, value: value, created: time.Now()})
+	for c.ll.Len() > c.size {
+		c.removeElement(c.ll.Back())
+	}
+}
+
+// Purge removes all entries from the cache.
+func (c *LRU[K, V]) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.ll.Init()
+	clear(c.items)
+	c.gen++
+}
+
+func (c *LRU[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+func (c *LRU[K, V]) removeElement(el *list.Element) {
+	c.ll.Remove(el)
+	delete(c.items, el.Value.(*entry[K, V]).key)
+}
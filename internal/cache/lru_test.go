@@ -0,0 +1,58 @@
+package cache_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mi-raf/cars-catalog/internal/cache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	c := cache.New[string, int](2, 0)
+	c.Add("a", 1)
+	c.Add("b", 2)
+	_, ok := c.Get("a")
+	assert.True(t, ok)
+
+	c.Add("c", 3)
+	_, ok = c.Get("b")
+	assert.False(t, ok)
+	v, ok := c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+	assert.Equal(t, 2, c.Len())
+}
+
+func TestLRUExpiresEntries(t *testing.T) {
+	c := cache.New[string, int](2, time.Millisecond)
+	c.Add("a", 1)
+	time.Sleep(5 * time.Millisecond)
+	_, ok := c.Get("a")
+	assert.False(t, ok)
+	assert.Equal(t, 0, c.Len())
+}
+
+func TestLRUPurge(t *testing.T) {
+	c := cache.New[string, int](2, 0)
+	c.Add("a", 1)
+	c.Add("b", 2)
+	c.Purge()
+	_, ok := c.Get("a")
+	assert.False(t, ok)
+	assert.Equal(t, 0, c.Len())
+}
+
+func TestLRUAddIfDropsValuesReadBeforePurge(t *testing.T) {
+	c := cache.New[string, int](2, 0)
+	gen := c.Generation()
+	c.Purge()
+	assert.False(t, c.AddIf(gen, "a", 1))
+	_, ok := c.Get("a")
+	assert.False(t, ok)
+
+	assert.True(t, c.AddIf(c.Generation(), "a", 2))
+	v, ok := c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 2, v)
+}
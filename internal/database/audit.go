@@ -0,0 +1,230 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype/zeronull"
+	"github.com/mi-raf/cars-catalog/internal/audit"
+	mod "github.com/mi-raf/cars-catalog/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	auditEntityCar    = "car"
+	auditEntityPeople = "people"
+
+	auditActionCreate  = "create"
+	auditActionUpdate  = "update"
+	auditActionDelete  = "delete"
+	auditActionRestore = "restore"
+	auditActionPurge   = "purge"
+
+	insertAudit = `INSERT INTO Audit_log (entity, entity_id, action, actor, request_id, before_a, after_a)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	auditColumns = `
+	id_a, entity, entity_id, action, actor, request_id, before_a, after_a, created_at`
+
+	// records made under earlier plates of the car are included up to the
+	// moment the plate was changed
+	selectCarHistory = `
+	SELECT` + auditColumns + `
+	FROM Audit_log AS a
+	WHERE a.entity = 'car' AND (a.entity_id = $1 OR EXISTS (
+		SELECT 1 FROM Plate_change AS pc JOIN Car AS c
+		ON pc.id_c = c.id_c
+		WHERE c.reg_num = $1 AND pc.old_reg_num = a.entity_id AND a.created_at <= pc.changed_at
+	))
+	ORDER BY created_at, id_a`
+
+	selectAuditSince = `
+	SELECT` + auditColumns + `
+	FROM Audit_log
+	WHERE created_at >= $1
+	ORDER BY created_at, id_a
+	LIMIT $2`
+
+	lockCarSnapshot = `
+	SELECT` + carColumns + carFrom + `
+	WHERE reg_num = $1
+	FOR UPDATE OF Car`
+)
+
+type (
+	// carSnapshot is the audited representation of a car. It is decoupled
+	// from the DTO so that the stored JSON stays stable.
+	carSnapshot struct {
+		Id         int64           `json:"id,omitempty"`
+		RegNum     string          `json:"regNum"`
+		Mark       string          `json:"mark"`
+		Model      string          `json:"model"`
+		Year       int32           `json:"year,omitempty"`
+		Version    int32           `json:"version"`
+		DeletedAt  *time.Time      `json:"deletedAt,omitempty"`
+		Owner      *peopleSnapshot `json:"owner,omitempty"`
+		PlateType  string          `json:"plateType,omitempty"`
+		RegionCode string          `json:"regionCode,omitempty"`
+		Vin        string          `json:"vin,omitempty"`
+
+		Color        string `json:"color,omitempty"`
+		BodyType     string `json:"bodyType,omitempty"`
+		Fuel         string `json:"fuel,omitempty"`
+		EngineVolume int32  `json:"engineVolume,omitempty"`
+		Transmission string `json:"transmission,omitempty"`
+		Mileage      int32  `json:"mileage,omitempty"`
+
+		SuspiciousRollback bool   `json:"suspiciousRollback,omitempty"`
+		Status             string `json:"status,omitempty"`
+		StatusReason       string `json:"statusReason,omitempty"`
+	}
+
+	peopleSnapshot struct {
+		Id         int64  `json:"id,omitempty"`
+		Name       string `json:"name"`
+		Surname    string `json:"surname"`
+		Patronymic string `json:"patronymic,omitempty"`
+	}
+)
+
+func newCarSnapshot(c *mod.CarDTO) *carSnapshot {
+	s := &carSnapshot{
+		Id:         c.ID,
+		RegNum:     c.RegNum,
+		Mark:       c.Mark,
+		Model:      c.Model,
+		Year:       c.Year,
+		Version:    c.Version,
+		PlateType:  c.PlateType,
+		RegionCode: c.RegionCode,
+		Vin:        c.VIN,
+
+		Color:        c.Color,
+		BodyType:     c.BodyType,
+		Fuel:         c.Fuel,
+		EngineVolume: c.EngineVolume,
+		Transmission: c.Transmission,
+		Mileage:      c.Mileage,
+
+		SuspiciousRollback: c.SuspiciousRollback,
+		Status:             c.Status,
+		StatusReason:       c.StatusReason,
+	}
+	if !c.DeletedAt.IsZero() {
+		s.DeletedAt = &c.DeletedAt
+	}
+	if c.Owner != nil {
+		s.Owner = newPeopleSnapshot(c.Owner)
+	}
+	return s
+}
+
+func newPeopleSnapshot(p *mod.PeopleDTO) *peopleSnapshot {
+	return &peopleSnapshot{
+		Id:         p.Id,
+		Name:       p.Name,
+		Surname:    p.Surname,
+		Patronymic: p.Patronymic,
+	}
+}
+
+// writeAudit records a mutation in the same transaction as the change itself.
+// A nil before or after snapshot is stored as NULL.
+func writeAudit(ctx context.Context, tx pgx.Tx, entity, entityID, action string, before, after any) error {
+	b, err := marshalSnapshot(before)
+	if err != nil {
+		return err
+	}
+	a, err := marshalSnapshot(after)
+	if err != nil {
+		return err
+	}
+	m := audit.FromContext(ctx)
+	_, err = tx.Exec(ctx, insertAudit, entity, entityID, action, m.Actor, zeronull.Text(m.RequestID), b, a)
+	if err != nil {
+		log.Error().Err(err).Str("entity", entity).Str("id", entityID).Str("action", action).Msg("can't write audit")
+		return err
+	}
+	return nil
+}
+
+// marshalSnapshot encodes a snapshot; nil snapshots become SQL NULL.
+func marshalSnapshot(s any) ([]byte, error) {
+	b, err := json.Marshal(s)
+	if err != nil || string(b) == "null" {
+		return nil, err
+	}
+	return b, nil
+}
+
+// snapshotCar locks the car row, soft deleted or not, and returns its state.
+// Nil is returned when there is no such car.
+func snapshotCar(ctx context.Context, tx pgx.Tx, regNum string) (*carSnapshot, error) {
+	c, err := scanCar(tx.QueryRow(ctx, lockCarSnapshot, regNum))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		log.Error().Err(err).Str("reg num", regNum).Msg("can't take car snapshot")
+		return nil, err
+	}
+	return newCarSnapshot(&c), nil
+}
+
+// mutateCar executes a statement changing one car and writes the car state
+// before and after it to the audit log. It returns the number of affected rows;
+// nothing is audited when no row was changed.
+func mutateCar(ctx context.Context, tx pgx.Tx, regNum, action, stmt string, args ...any) (int64, error) {
+	before, err := snapshotCar(ctx, tx, regNum)
+	if err != nil {
+		return 0, err
+	}
+	tag, err := tx.Exec(ctx, stmt, args...)
+	if err != nil {
+		return 0, err
+	}
+	if tag.RowsAffected() == 0 {
+		return 0, nil
+	}
+	after, err := snapshotCar(ctx, tx, regNum)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), writeAudit(ctx, tx, auditEntityCar, regNum, action, before, after)
+}
+
+func (r *PgCarRepository) History(ctx context.Context, regNum string) ([]mod.AuditRecord, error) {
+	rows, err := r.pool.Query(ctx, selectCarHistory, regNum)
+	if err != nil {
+		log.Error().Err(err).Str("reg num", regNum).Msg("can't get car history")
+		return nil, err
+	}
+	return collectAudit(rows)
+}
+
+func (r *PgCarRepository) AuditSince(ctx context.Context, since time.Time, limit int) ([]mod.AuditRecord, error) {
+	rows, err := r.pool.Query(ctx, selectAuditSince, since, limit)
+	if err != nil {
+		log.Error().Err(err).Time("since", since).Msg("can't get audit records")
+		return nil, err
+	}
+	return collectAudit(rows)
+}
+
+func collectAudit(rows pgx.Rows) ([]mod.AuditRecord, error) {
+	records, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (mod.AuditRecord, error) {
+		a := mod.AuditRecord{}
+		var reqID zeronull.Text
+		err := row.Scan(&a.ID, &a.Entity, &a.EntityID, &a.Action, &a.Actor, &reqID, &a.Before, &a.After, &a.CreatedAt)
+		a.RequestID = string(reqID)
+		return a, err
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("can't read audit records")
+		return nil, err
+	}
+	return records, nil
+}
@@ -0,0 +1,92 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/mi-raf/cars-catalog/internal"
+	mod "github.com/mi-raf/cars-catalog/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+// MAX_FACET_VALUES limits the number of values counted for one facet.
+const MAX_FACET_VALUES = 50
+
+const facetQuery = `
+	SELECT %s AS value_f, count(*) AS count_f
+	%s
+	GROUP BY value_f
+	ORDER BY count_f DESC, value_f
+	LIMIT @limit`
+
+type facet struct {
+	key string
+	// drop removes the facet's own condition from the filter, so that the
+	// counts show what selecting another value would give
+	drop func(*mod.CarFilter)
+}
+
+var facets = map[string]facet{
+	mod.FacetMark:  {"mark", func(f *mod.CarFilter) { f.Mark = "" }},
+	mod.FacetModel: {"model", func(f *mod.CarFilter) { f.Model = "" }},
+	mod.FacetYear:  {"COALESCE(year_c::text, 'unknown')", func(f *mod.CarFilter) { f.Year = 0 }},
+}
+
+// GetAllWithFacets returns a page of cars like GetAll together with per-value
+// counts of the requested facets. All queries go to the database in one batch.
+func (r *PgCarRepository) GetAllWithFacets(ctx context.Context, filter mod.CarFilter, offset, limit int, names []string) ([]mod.CarDTO, map[string][]mod.StatGroup, error) {
+	b := &pgx.Batch{}
+	q, args, err := carsQuery(filter, offset, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	b.Queue(q, args)
+	for _, name := range names {
+		f, ok := facets[name]
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: %s", internal.ErrUnknownGroup, name)
+		}
+		ff := filter
+		f.drop(&ff)
+		from, fargs := filteredCars(ff)
+		fargs["limit"] = MAX_FACET_VALUES
+		b.Queue(fmt.Sprintf(facetQuery, f.key, from), fargs)
+	}
+
+	br := r.pool.SendBatch(ctx, b)
+	defer br.Close()
+
+	rows, err := br.Query()
+	if err != nil {
+		log.Error().Err(err).Msg("can't get cars with facets")
+		return nil, nil, err
+	}
+	cars, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (mod.CarDTO, error) {
+		return scanCar(row)
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("can't read cars with facets")
+		return nil, nil, err
+	}
+
+	counts := make(map[string][]mod.StatGroup, len(names))
+	for _, name := range names {
+		rows, err := br.Query()
+		if err != nil {
+			log.Error().Err(err).Str("facet", name).Msg("can't count facet")
+			return nil, nil, err
+		}
+		groups, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (mod.StatGroup, error) {
+			g := mod.StatGroup{}
+			err := row.Scan(&g.Key, &g.Count)
+			return g, err
+		})
+		if err != nil {
+			log.Error().Err(err).Str("facet", name).Msg("can't read facet")
+			return nil, nil, err
+		}
+		counts[name] = groups
+	}
+	return cars, counts, nil
+}
@@ -0,0 +1,66 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/mi-raf/cars-catalog/internal"
+	mod "github.com/mi-raf/cars-catalog/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+// Car_history is filled by a trigger on Car. Each row keeps a version of the
+// car as JSON together with the period it was valid in; the versions are
+// turned back into Car records so that the usual columns and filters apply.
+const (
+	carHistoryFrom = `
+	FROM Car_history AS h
+	CROSS JOIN LATERAL jsonb_populate_record(NULL::Car, h.data) AS Car
+	JOIN People AS p
+	ON Car.id_p = p.id_p`
+
+	carAsOfCond = `
+	h.valid_from <= @as_of AND (h.valid_to IS NULL OR h.valid_to > @as_of) AND`
+
+	searchCarAsOfWithFil = `
+	SELECT` + carColumns + carHistoryFrom + `
+	WHERE` + carAsOfCond + carFilterCond + `
+	ORDER BY %s
+	LIMIT @limit
+	OFFSET @offset`
+
+	// the plate is resolved to a car first: the car which had it at the
+	// instant, else the car which has it now, else the car which left it last
+	selectCarAsOf = `
+	WITH plate_car AS (
+		SELECT id_c FROM (
+			SELECT id_c_h AS id_c, 1 AS pref, valid_from AS at FROM Car_history
+			WHERE reg_num_h = $1 AND valid_from <= $2 AND (valid_to IS NULL OR valid_to > $2)
+			UNION ALL
+			SELECT id_c, 2, now() FROM Car WHERE reg_num = $1
+			UNION ALL
+			SELECT id_c, 3, changed_at FROM Plate_change WHERE old_reg_num = $1
+		) AS c
+		ORDER BY pref, at DESC
+		LIMIT 1
+	)
+	SELECT` + carColumns + carHistoryFrom + `
+	WHERE h.id_c_h = (SELECT id_c FROM plate_car) AND h.valid_from <= $2 AND (h.valid_to IS NULL OR h.valid_to > $2) AND deleted_at IS NULL`
+)
+
+// GetAsOf returns the car as it was at the given instant. The plate may be one
+// the car had before or after the instant.
+func (r *PgCarRepository) GetAsOf(ctx context.Context, regNum string, asOf time.Time) (*mod.CarDTO, error) {
+	c, err := scanCar(r.pool.QueryRow(ctx, selectCarAsOf, regNum, asOf))
+	if errors.Is(err, pgx.ErrNoRows) {
+		log.Debug().Str("reg num", regNum).Time("as of", asOf).Msg("car not found")
+		return nil, internal.ErrNotFound
+	}
+	if err != nil {
+		log.Error().Err(err).Str("reg num", regNum).Time("as of", asOf).Msg("can't get car")
+		return nil, err
+	}
+	return &c, nil
+}
@@ -0,0 +1,182 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype/zeronull"
+	"github.com/mi-raf/cars-catalog/internal/audit"
+	mod "github.com/mi-raf/cars-catalog/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	createCarImport = `CREATE TEMP TABLE Car_import (
+		reg_num varchar(12),
+		mark varchar(40),
+		model varchar(40),
+		year_c integer,
+		name_p varchar(20),
+		surname_p varchar(60),
+		patronymic_p varchar(40),
+		plate_type varchar(20),
+		region_code varchar(3),
+		vin varchar(17),
+		color varchar(30),
+		body_type varchar(20),
+		fuel varchar(20),
+		engine_volume integer,
+		transmission varchar(20),
+		mileage integer
+	) ON COMMIT DROP`
+
+	// importOwnerCond matches people the same way as selectOwnerID
+	importOwnerCond = `
+	p.name_p = i.name_p AND p.surname_p = i.surname_p AND CASE WHEN p.patronymic_p IS NULL THEN true ELSE p.patronymic_p = i.patronymic_p END`
+
+	insertImportOwners = `
+	INSERT INTO People (name_p, surname_p, patronymic_p)
+	SELECT DISTINCT i.name_p, i.surname_p, i.patronymic_p
+	FROM Car_import AS i
+	WHERE NOT EXISTS (SELECT 1 FROM People AS p WHERE` + importOwnerCond + `)
+	ON CONFLICT DO NOTHING
+	RETURNING id_p, name_p, surname_p, patronymic_p`
+
+	// existing cars, soft deleted ones included, are left untouched, as are
+	// cars whose VIN belongs to another car
+	insertImportCars = `
+	INSERT INTO Car (reg_num, mark, model, year_c, id_p, plate_type, region_code, vin, color, body_type, fuel, engine_volume, transmission, mileage)
+	SELECT i.reg_num, i.mark, i.model, i.year_c, o.id_p, i.plate_type, i.region_code, i.vin, i.color, i.body_type, i.fuel, i.engine_volume, i.transmission, i.mileage
+	FROM Car_import AS i CROSS JOIN LATERAL (
+		SELECT id_p FROM People AS p WHERE` + importOwnerCond + `
+		ORDER BY id_p LIMIT 1
+	) AS o
+	ON CONFLICT DO NOTHING
+	RETURNING reg_num`
+
+	openImportOwnership = `
+	INSERT INTO Ownership (reg_num, id_p)
+	SELECT reg_num, id_p FROM Car WHERE reg_num = ANY($1)`
+
+	selectImportedCars = `
+	SELECT` + carColumns + carFrom + `
+	WHERE reg_num = ANY($1)
+	ORDER BY reg_num`
+)
+
+var (
+	carImportColumns = []string{"reg_num", "mark", "model", "year_c", "name_p", "surname_p", "patronymic_p", "plate_type", "region_code", "vin",
+		"color", "body_type", "fuel", "engine_volume", "transmission", "mileage"}
+	auditColumnNames = []string{"entity", "entity_id", "action", "actor", "request_id", "before_a", "after_a"}
+)
+
+// Import adds a batch of cars in one transaction and returns the registration
+// numbers actually inserted; cars which already exist are skipped. Rows are
+// loaded with COPY into a staging table first. With dryRun the transaction is
+// rolled back after the work is done, so the result shows what would happen.
+func (r *PgCarRepository) Import(ctx context.Context, cars []mod.CarDTO, dryRun bool) ([]string, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("can't open transaction for import")
+		return nil, err
+	}
+
+	defer func() {
+		err = tx.Rollback(ctx)
+		if !errors.Is(err, pgx.ErrTxClosed) {
+			log.Error().Err(err).Msg("Undefinded error in tx")
+		}
+	}()
+
+	if _, err = tx.Exec(ctx, createCarImport); err != nil {
+		log.Error().Err(err).Msg("can't create import table")
+		return nil, err
+	}
+	_, err = tx.CopyFrom(ctx, pgx.Identifier{"car_import"}, carImportColumns, pgx.CopyFromSlice(len(cars), func(i int) ([]any, error) {
+		c := &cars[i]
+		return []any{c.RegNum, c.Mark, c.Model, zeronull.Int4(c.Year), c.Owner.Name, c.Owner.Surname, zeronull.Text(c.Owner.Patronymic), zeronull.Text(c.PlateType), zeronull.Text(c.RegionCode), zeronull.Text(c.VIN),
+			zeronull.Text(c.Color), zeronull.Text(c.BodyType), zeronull.Text(c.Fuel), zeronull.Int4(c.EngineVolume), zeronull.Text(c.Transmission), zeronull.Int4(c.Mileage)}, nil
+	}))
+	if err != nil {
+		log.Error().Err(err).Msg("can't copy cars to import table")
+		return nil, err
+	}
+
+	var auditRows [][]any
+	m := audit.FromContext(ctx)
+
+	rows, err := tx.Query(ctx, insertImportOwners)
+	if err != nil {
+		log.Error().Err(err).Msg("can't import owners")
+		return nil, err
+	}
+	owners, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (mod.PeopleDTO, error) {
+		p := mod.PeopleDTO{}
+		var patronymic zeronull.Text
+		err := row.Scan(&p.Id, &p.Name, &p.Surname, &patronymic)
+		p.Patronymic = string(patronymic)
+		return p, err
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("can't read imported owners")
+		return nil, err
+	}
+	for _, p := range owners {
+		after, err := marshalSnapshot(newPeopleSnapshot(&p))
+		if err != nil {
+			return nil, err
+		}
+		auditRows = append(auditRows, []any{auditEntityPeople, strconv.FormatInt(p.Id, 10), auditActionCreate, m.Actor, zeronull.Text(m.RequestID), nil, after})
+	}
+
+	rows, err = tx.Query(ctx, insertImportCars)
+	if err != nil {
+		log.Error().Err(err).Msg("can't import cars")
+		return nil, err
+	}
+	imported, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		log.Error().Err(err).Msg("can't read imported cars")
+		return nil, err
+	}
+
+	if len(imported) > 0 {
+		if _, err = tx.Exec(ctx, openImportOwnership, imported); err != nil {
+			log.Error().Err(err).Msg("can't open ownership for imported cars")
+			return nil, err
+		}
+		rows, err = tx.Query(ctx, selectImportedCars, imported)
+		if err != nil {
+			log.Error().Err(err).Msg("can't read imported cars")
+			return nil, err
+		}
+		snapshots, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*carSnapshot, error) {
+			c, err := scanCar(row)
+			return newCarSnapshot(&c), err
+		})
+		if err != nil {
+			log.Error().Err(err).Msg("can't take imported car snapshots")
+			return nil, err
+		}
+		for _, s := range snapshots {
+			after, err := marshalSnapshot(s)
+			if err != nil {
+				return nil, err
+			}
+			auditRows = append(auditRows, []any{auditEntityCar, s.RegNum, auditActionCreate, m.Actor, zeronull.Text(m.RequestID), nil, after})
+		}
+	}
+
+	if _, err = tx.CopyFrom(ctx, pgx.Identifier{"audit_log"}, auditColumnNames, pgx.CopyFromRows(auditRows)); err != nil {
+		log.Error().Err(err).Msg("can't write import audit")
+		return nil, err
+	}
+
+	log.Debug().Int("cars", len(cars)).Int("imported", len(imported)).Int("owners", len(owners)).Bool("dry run", dryRun).Msg("import cars")
+	if dryRun {
+		return imported, tx.Rollback(ctx)
+	}
+	return imported, tx.Commit(ctx)
+}
@@ -0,0 +1,193 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgtype/zeronull"
+	"github.com/mi-raf/cars-catalog/internal"
+	mod "github.com/mi-raf/cars-catalog/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	maintenanceColumns = `
+	id_m, done_on, mileage, work_type, cost, notes, created_at, updated_at`
+
+	// records are reached through the car so that an id of another car's
+	// record is not found
+	maintenanceOfCar = `
+	id_c = (SELECT id_c FROM Car WHERE reg_num = $1 AND deleted_at IS NULL)`
+
+	insertMaintenance = `INSERT INTO Maintenance (id_c, done_on, mileage, work_type, cost, notes)
+	SELECT id_c, $2, $3, $4, $5, $6 FROM Car WHERE reg_num = $1 AND deleted_at IS NULL
+	RETURNING id_m, created_at, updated_at`
+
+	selectMaintenance = `
+	SELECT` + maintenanceColumns + `
+	FROM Maintenance
+	WHERE id_m = $2 AND` + maintenanceOfCar
+
+	selectMaintenanceWithFil = `
+	SELECT` + maintenanceColumns + `
+	FROM Maintenance
+	WHERE` + maintenanceOfCar + ` AND
+		($2::date IS NULL OR done_on >= $2::date) AND
+		($3::date IS NULL OR done_on <= $3::date) AND
+		($4::varchar IS NULL OR work_type = $4::varchar)
+	ORDER BY done_on, id_m`
+
+	updateMaintenance = `UPDATE Maintenance SET
+		done_on = $3, mileage = $4, work_type = $5, cost = $6, notes = $7, updated_at = now()
+	WHERE id_m = $2 AND` + maintenanceOfCar + `
+	RETURNING created_at, updated_at`
+
+	deleteMaintenance = `DELETE FROM Maintenance WHERE id_m = $2 AND` + maintenanceOfCar
+
+	selectLastServices = "SELECT id_c, max(done_on) FROM Maintenance WHERE id_c = ANY($1) GROUP BY id_c"
+)
+
+// AddMaintenance records a maintenance event of the active car and fills in
+// the id and timestamps of m.
+func (r *PgCarRepository) AddMaintenance(ctx context.Context, regNum string, m *mod.Maintenance) error {
+	err := r.pool.QueryRow(ctx, insertMaintenance, regNum, m.DoneOn, zeronull.Int4(m.Mileage), m.WorkType, zeronull.Int8(m.Cost), zeronull.Text(m.Notes)).
+		Scan(&m.ID, &m.CreatedAt, &m.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return internal.ErrNotFound
+	}
+	if err != nil {
+		log.Error().Err(err).Str("reg num", regNum).Msg("can't add maintenance")
+		return err
+	}
+	log.Debug().Str("reg num", regNum).Int64("id", m.ID).Msg("add maintenance")
+	return nil
+}
+
+func (r *PgCarRepository) GetMaintenance(ctx context.Context, regNum string, id int64) (*mod.Maintenance, error) {
+	m, err := scanMaintenance(r.pool.QueryRow(ctx, selectMaintenance, regNum, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, r.missingMaintenanceError(ctx, regNum)
+	}
+	if err != nil {
+		log.Error().Err(err).Str("reg num", regNum).Int64("id", id).Msg("can't get maintenance")
+		return nil, err
+	}
+	return &m, nil
+}
+
+// ListMaintenance returns the maintenance of the car matching the filter,
+// oldest first.
+func (r *PgCarRepository) ListMaintenance(ctx context.Context, regNum string, filter mod.MaintenanceFilter) ([]mod.Maintenance, error) {
+	rows, err := r.pool.Query(ctx, selectMaintenanceWithFil, regNum, optionalDate(filter.From), optionalDate(filter.To), zeronull.Text(filter.WorkType))
+	if err != nil {
+		log.Error().Err(err).Str("reg num", regNum).Msg("can't list maintenance")
+		return nil, err
+	}
+	records, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (mod.Maintenance, error) {
+		return scanMaintenance(row)
+	})
+	if err != nil {
+		log.Error().Err(err).Str("reg num", regNum).Msg("can't read maintenance")
+		return nil, err
+	}
+	if len(records) > 0 {
+		return records, nil
+	}
+
+	var found string
+	err = r.pool.QueryRow(ctx, searchActiveRegNum, regNum).Scan(&found)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, internal.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// UpdateMaintenance replaces the record m.ID of the car and refreshes the
+// timestamps of m.
+func (r *PgCarRepository) UpdateMaintenance(ctx context.Context, regNum string, m *mod.Maintenance) error {
+	err := r.pool.QueryRow(ctx, updateMaintenance, regNum, m.ID, m.DoneOn, zeronull.Int4(m.Mileage), m.WorkType, zeronull.Int8(m.Cost), zeronull.Text(m.Notes)).
+		Scan(&m.CreatedAt, &m.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return r.missingMaintenanceError(ctx, regNum)
+	}
+	if err != nil {
+		log.Error().Err(err).Str("reg num", regNum).Int64("id", m.ID).Msg("can't update maintenance")
+		return err
+	}
+	log.Debug().Str("reg num", regNum).Int64("id", m.ID).Msg("update maintenance")
+	return nil
+}
+
+func (r *PgCarRepository) DeleteMaintenance(ctx context.Context, regNum string, id int64) error {
+	tag, err := r.pool.Exec(ctx, deleteMaintenance, regNum, id)
+	if err != nil {
+		log.Error().Err(err).Str("reg num", regNum).Int64("id", id).Msg("can't delete maintenance")
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return r.missingMaintenanceError(ctx, regNum)
+	}
+	log.Debug().Str("reg num", regNum).Int64("id", id).Msg("delete maintenance")
+	return nil
+}
+
+// LastServices returns the date of the latest maintenance of every car which
+// has any, by car id.
+func (r *PgCarRepository) LastServices(ctx context.Context, carIDs []int64) (map[int64]time.Time, error) {
+	res := make(map[int64]time.Time, len(carIDs))
+	if len(carIDs) < 1 {
+		return res, nil
+	}
+	rows, err := r.pool.Query(ctx, selectLastServices, carIDs)
+	if err != nil {
+		log.Error().Err(err).Msg("can't get last services")
+		return nil, err
+	}
+	var id int64
+	var doneOn time.Time
+	_, err = pgx.ForEachRow(rows, []any{&id, &doneOn}, func() error {
+		res[id] = doneOn
+		return nil
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("can't read last services")
+		return nil, err
+	}
+	return res, nil
+}
+
+// missingMaintenanceError explains why a record was not found: either the
+// car does not exist or it has no such record.
+func (r *PgCarRepository) missingMaintenanceError(ctx context.Context, regNum string) error {
+	var found string
+	err := r.pool.QueryRow(ctx, searchActiveRegNum, regNum).Scan(&found)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return internal.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return internal.ErrNoMaintenance
+}
+
+func optionalDate(t time.Time) pgtype.Date {
+	return pgtype.Date{Time: t, Valid: !t.IsZero()}
+}
+
+func scanMaintenance(row pgx.Row) (mod.Maintenance, error) {
+	m := mod.Maintenance{}
+	var mileage zeronull.Int4
+	var cost zeronull.Int8
+	var notes zeronull.Text
+	err := row.Scan(&m.ID, &m.DoneOn, &mileage, &m.WorkType, &cost, &notes, &m.CreatedAt, &m.UpdatedAt)
+	m.Mileage = int32(mileage)
+	m.Cost = int64(cost)
+	m.Notes = string(notes)
+	return m, err
+}
@@ -0,0 +1,144 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/mi-raf/cars-catalog/internal"
+	mod "github.com/mi-raf/cars-catalog/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	auditActionMileage = "mileage"
+
+	lockCarMileage = "SELECT id_c, mileage FROM Car WHERE reg_num = $1 AND deleted_at IS NULL FOR UPDATE"
+
+	// the readings just before and just after the date; a new reading is in
+	// order when it lies between them
+	selectMileageNeighbours = `
+	SELECT
+		(SELECT mileage FROM Mileage_log WHERE id_c = $1 AND read_on <= $2 ORDER BY read_on DESC, id_ml DESC LIMIT 1),
+		(SELECT mileage FROM Mileage_log WHERE id_c = $1 AND read_on > $2 ORDER BY read_on, id_ml LIMIT 1)`
+
+	insertMileage = "INSERT INTO Mileage_log (id_c, mileage, read_on, rollback) VALUES ($1, $2, $3, $4)"
+
+	// the car shows its latest reading; a logged rollback marks it for good
+	updateCarMileage = `UPDATE Car SET
+		mileage = CASE WHEN $2::boolean THEN $3::integer ELSE mileage END,
+		suspicious_rollback = suspicious_rollback OR $4::boolean,
+		version = version + 1
+	WHERE reg_num = $1 AND deleted_at IS NULL`
+
+	selectMileage = `
+	SELECT mileage, read_on, rollback, created_at
+	FROM Mileage_log
+	WHERE id_c = (SELECT id_c FROM Car WHERE reg_num = $1)
+	ORDER BY read_on, id_ml`
+)
+
+// AddMileage logs an odometer reading of the car. A reading lower than the one
+// taken before it or higher than the one taken after it is refused with
+// internal.ErrMileageRollback unless override is set; then it is logged as a
+// rollback and the car is marked as suspicious.
+func (r *PgCarRepository) AddMileage(ctx context.Context, regNum string, reading mod.MileageReading, override bool) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("can't open transaction for mileage")
+		return err
+	}
+
+	defer func() {
+		err = tx.Rollback(ctx)
+		if !errors.Is(err, pgx.ErrTxClosed) {
+			log.Error().Err(err).Msg("Undefinded error in tx")
+		}
+	}()
+
+	if err = checkWritable(ctx, tx, regNum, writeMileage); err != nil {
+		return err
+	}
+	latest, rollback, err := logMileage(ctx, tx, regNum, reading, override)
+	if err != nil {
+		return err
+	}
+	if latest || rollback {
+		if _, err = mutateCar(ctx, tx, regNum, auditActionMileage, updateCarMileage, regNum, latest, reading.Mileage, rollback); err != nil {
+			log.Error().Err(err).Str("reg num", regNum).Msg("can't update car mileage")
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// logMileage checks and logs a reading in the caller's transaction; the
+// caller updates the car. latest tells whether the reading is the car's
+// mileage now. The car's mileage counts as the reading before a new latest
+// one, so mileage set before the log was kept can not be lowered either.
+func logMileage(ctx context.Context, tx pgx.Tx, regNum string, reading mod.MileageReading, override bool) (latest, rollback bool, err error) {
+	var carID int64
+	var current pgtype.Int4
+	err = tx.QueryRow(ctx, lockCarMileage, regNum).Scan(&carID, &current)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, false, internal.ErrNotFound
+	}
+	if err != nil {
+		log.Error().Err(err).Str("reg num", regNum).Msg("can't lock car for mileage")
+		return false, false, err
+	}
+
+	var before, after pgtype.Int4
+	if err = tx.QueryRow(ctx, selectMileageNeighbours, carID, reading.ReadOn).Scan(&before, &after); err != nil {
+		log.Error().Err(err).Str("reg num", regNum).Msg("can't get neighbour readings")
+		return false, false, err
+	}
+	latest = !after.Valid
+	if latest && current.Valid && (!before.Valid || current.Int32 > before.Int32) {
+		before = current
+	}
+	rollback = (before.Valid && reading.Mileage < before.Int32) || (after.Valid && reading.Mileage > after.Int32)
+	if rollback && !override {
+		log.Debug().Str("reg num", regNum).Int32("mileage", reading.Mileage).Int32("before", before.Int32).Int32("after", after.Int32).Msg("mileage out of order")
+		return false, false, internal.ErrMileageRollback
+	}
+
+	if _, err = tx.Exec(ctx, insertMileage, carID, reading.Mileage, reading.ReadOn, rollback); err != nil {
+		log.Error().Err(err).Str("reg num", regNum).Msg("can't log mileage")
+		return false, false, err
+	}
+	log.Debug().Str("reg num", regNum).Int32("mileage", reading.Mileage).Bool("rollback", rollback).Msg("log mileage")
+	return latest, rollback, nil
+}
+
+// Mileage returns the odometer readings of the car, oldest first.
+func (r *PgCarRepository) Mileage(ctx context.Context, regNum string) ([]mod.MileageReading, error) {
+	rows, err := r.pool.Query(ctx, selectMileage, regNum)
+	if err != nil {
+		log.Error().Err(err).Str("reg num", regNum).Msg("can't get mileage")
+		return nil, err
+	}
+	readings, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (mod.MileageReading, error) {
+		m := mod.MileageReading{}
+		err := row.Scan(&m.Mileage, &m.ReadOn, &m.Rollback, &m.CreatedAt)
+		return m, err
+	})
+	if err != nil {
+		log.Error().Err(err).Str("reg num", regNum).Msg("can't read mileage")
+		return nil, err
+	}
+	if len(readings) > 0 {
+		return readings, nil
+	}
+
+	var found string
+	err = r.pool.QueryRow(ctx, searchActiveRegNum, regNum).Scan(&found)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, internal.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return readings, nil
+}
@@ -0,0 +1,135 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype/zeronull"
+	"github.com/mi-raf/cars-catalog/internal"
+	mod "github.com/mi-raf/cars-catalog/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	auditActionTransfer = "transfer"
+
+	closeOwnership = "UPDATE Ownership SET valid_to = now() WHERE reg_num = $1 AND valid_to IS NULL AND id_p <> $2"
+	openOwnership  = `INSERT INTO Ownership (reg_num, id_p)
+	SELECT $1, $2 WHERE NOT EXISTS (SELECT 1 FROM Ownership WHERE reg_num = $1 AND valid_to IS NULL)`
+	lockCarOwner = "SELECT id_p FROM Car WHERE reg_num = $1 AND deleted_at IS NULL FOR UPDATE"
+	transferCar  = `UPDATE Car SET id_p = $2, version = version + 1
+	WHERE reg_num = $1 AND deleted_at IS NULL AND ($3::integer IS NULL OR version = $3::integer)`
+
+	selectOwners = `
+	SELECT p.id_p, p.name_p, p.surname_p, p.patronymic_p, o.valid_from, o.valid_to
+	FROM Ownership AS o JOIN People AS p
+	ON o.id_p = p.id_p
+	WHERE o.reg_num = $1
+	ORDER BY o.valid_from, o.id_o`
+)
+
+// changeOwnership closes the current ownership of the car if it belongs to
+// somebody else and opens a new one for ownerID. Both periods share the
+// transaction time as their boundary.
+func changeOwnership(ctx context.Context, tx pgx.Tx, regNum string, ownerID int64) error {
+	if _, err := tx.Exec(ctx, closeOwnership, regNum, ownerID); err != nil {
+		log.Error().Err(err).Str("reg num", regNum).Msg("can't close ownership")
+		return err
+	}
+	if _, err := tx.Exec(ctx, openOwnership, regNum, ownerID); err != nil {
+		log.Error().Err(err).Str("reg num", regNum).Msg("can't open ownership")
+		return err
+	}
+	return nil
+}
+
+// Transfer hands the car over to a new owner. A non-zero version makes the
+// transfer conditional as for Update.
+func (r *PgCarRepository) Transfer(ctx context.Context, regNum string, owner *mod.PeopleDTO, version int32) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("can't open transaction for transfer")
+		return err
+	}
+
+	defer func() {
+		err = tx.Rollback(ctx)
+		if !errors.Is(err, pgx.ErrTxClosed) {
+			log.Error().Err(err).Msg("Undefinded error in tx")
+		}
+	}()
+
+	if err = checkWritable(ctx, tx, regNum, writeTransfer); err != nil {
+		return err
+	}
+	var currentID zeronull.Int8
+	err = tx.QueryRow(ctx, lockCarOwner, regNum).Scan(&currentID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return internal.ErrNotFound
+	}
+	if err != nil {
+		log.Error().Err(err).Str("reg num", regNum).Msg("can't get current owner")
+		return err
+	}
+
+	ownerID, err := findOrCreateOwner(ctx, tx, owner)
+	if err != nil {
+		log.Error().Err(err).Msg("can't find new owner")
+		return err
+	}
+	if int64(currentID) == ownerID {
+		log.Debug().Str("reg num", regNum).Int64("owner id", ownerID).Msg("car already belongs to owner")
+		return internal.ErrSameOwner
+	}
+
+	affected, err := mutateCar(ctx, tx, regNum, auditActionTransfer, transferCar, regNum, ownerID, zeronull.Int4(version))
+	if err != nil {
+		log.Error().Err(err).Str("reg num", regNum).Msg("can't transfer car")
+		return err
+	}
+	if affected == 0 {
+		return internal.ErrVersionMismatch
+	}
+	if err = changeOwnership(ctx, tx, regNum, ownerID); err != nil {
+		return err
+	}
+	log.Debug().Str("reg num", regNum).Int64("owner id", ownerID).Msg("transfer car")
+	return tx.Commit(ctx)
+}
+
+// Owners returns the chain of the car's owners, oldest first.
+func (r *PgCarRepository) Owners(ctx context.Context, regNum string) ([]mod.Ownership, error) {
+	rows, err := r.pool.Query(ctx, selectOwners, regNum)
+	if err != nil {
+		log.Error().Err(err).Str("reg num", regNum).Msg("can't get owners")
+		return nil, err
+	}
+	owners, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (mod.Ownership, error) {
+		o := mod.Ownership{}
+		var p zeronull.Text
+		var to zeronull.Timestamptz
+		err := row.Scan(&o.Owner.Id, &o.Owner.Name, &o.Owner.Surname, &p, &o.ValidFrom, &to)
+		o.Owner.Patronymic = string(p)
+		o.ValidTo = time.Time(to)
+		return o, err
+	})
+	if err != nil {
+		log.Error().Err(err).Str("reg num", regNum).Msg("can't read owners")
+		return nil, err
+	}
+	if len(owners) > 0 {
+		return owners, nil
+	}
+
+	var found string
+	err = r.pool.QueryRow(ctx, searchRegNum, regNum).Scan(&found)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, internal.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return owners, nil
+}
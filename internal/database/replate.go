@@ -0,0 +1,135 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype/zeronull"
+	"github.com/mi-raf/cars-catalog/internal"
+	mod "github.com/mi-raf/cars-catalog/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	auditActionReplate = "replate"
+
+	carRegNumKey = "car_reg_num_key"
+
+	// Ownership follows the new plate through ON UPDATE CASCADE
+	replateCar = `UPDATE Car SET reg_num = $2, plate_type = $3, region_code = $4, version = version + 1
+	WHERE reg_num = $1 AND deleted_at IS NULL AND ($5::integer IS NULL OR version = $5::integer)
+	RETURNING id_c`
+
+	insertPlateChange = "INSERT INTO Plate_change (id_c, old_reg_num, new_reg_num) VALUES ($1, $2, $3)"
+
+	selectPlateChanges = `
+	SELECT old_reg_num, new_reg_num, changed_at
+	FROM Plate_change
+	WHERE id_c = (SELECT id_c FROM Car WHERE reg_num = $1)
+	ORDER BY changed_at, id_pc`
+
+	// the plate may have been given to several cars in turn, the car which
+	// left it last is the one looked for
+	selectCurrentPlate = `
+	SELECT c.reg_num
+	FROM Plate_change AS pc JOIN Car AS c
+	ON pc.id_c = c.id_c
+	WHERE pc.old_reg_num = $1 AND c.deleted_at IS NULL
+	ORDER BY pc.changed_at DESC, pc.id_pc DESC
+	LIMIT 1`
+)
+
+// Replate gives the car a new registration number. The car keeps its id,
+// owners and history; the change is recorded in Plate_change and audited
+// under the new plate. A non-zero version makes it conditional as for Update.
+func (r *PgCarRepository) Replate(ctx context.Context, req mod.Replate) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("can't open transaction for replate")
+		return err
+	}
+
+	defer func() {
+		err = tx.Rollback(ctx)
+		if !errors.Is(err, pgx.ErrTxClosed) {
+			log.Error().Err(err).Msg("Undefinded error in tx")
+		}
+	}()
+
+	if err = checkWritable(ctx, tx, req.RegNum, writeReplate); err != nil {
+		return err
+	}
+	before, err := snapshotCar(ctx, tx, req.RegNum)
+	if err != nil {
+		return err
+	}
+	var carID int64
+	err = tx.QueryRow(ctx, replateCar, req.RegNum, req.NewRegNum, zeronull.Text(req.PlateType), zeronull.Text(req.RegionCode), zeronull.Int4(req.Version)).Scan(&carID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		log.Debug().Str("reg num", req.RegNum).Int32("version", req.Version).Msg("car not replated")
+		return missingCarError(tx.QueryRow(ctx, searchActiveRegNum, req.RegNum))
+	}
+	if err != nil {
+		log.Error().Err(err).Str("reg num", req.RegNum).Str("new reg num", req.NewRegNum).Msg("can't replate car")
+		return plateConflict(err)
+	}
+	if _, err = tx.Exec(ctx, insertPlateChange, carID, req.RegNum, req.NewRegNum); err != nil {
+		log.Error().Err(err).Str("reg num", req.RegNum).Msg("can't record plate change")
+		return err
+	}
+	after, err := snapshotCar(ctx, tx, req.NewRegNum)
+	if err != nil {
+		return err
+	}
+	if err = writeAudit(ctx, tx, auditEntityCar, req.NewRegNum, auditActionReplate, before, after); err != nil {
+		return err
+	}
+	log.Debug().Str("reg num", req.RegNum).Str("new reg num", req.NewRegNum).Msg("replate car")
+	return tx.Commit(ctx)
+}
+
+// Plates returns the plate changes of the car known by its current plate, oldest first.
+func (r *PgCarRepository) Plates(ctx context.Context, regNum string) ([]mod.PlateChange, error) {
+	rows, err := r.pool.Query(ctx, selectPlateChanges, regNum)
+	if err != nil {
+		log.Error().Err(err).Str("reg num", regNum).Msg("can't get plate changes")
+		return nil, err
+	}
+	changes, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (mod.PlateChange, error) {
+		pc := mod.PlateChange{}
+		err := row.Scan(&pc.OldRegNum, &pc.NewRegNum, &pc.ChangedAt)
+		return pc, err
+	})
+	if err != nil {
+		log.Error().Err(err).Str("reg num", regNum).Msg("can't read plate changes")
+		return nil, err
+	}
+	return changes, nil
+}
+
+// CurrentPlate returns the plate of the active car which used to have regNum,
+// or internal.ErrNotFound.
+func (r *PgCarRepository) CurrentPlate(ctx context.Context, regNum string) (string, error) {
+	var current string
+	err := r.pool.QueryRow(ctx, selectCurrentPlate, regNum).Scan(&current)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", internal.ErrNotFound
+	}
+	if err != nil {
+		log.Error().Err(err).Str("reg num", regNum).Msg("can't get current plate")
+		return "", err
+	}
+	return current, nil
+}
+
+// plateConflict reports a plate already used by another car, soft deleted
+// ones included, as internal.ErrPlateTaken.
+func plateConflict(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation && pgErr.ConstraintName == carRegNumKey {
+		return internal.ErrPlateTaken
+	}
+	return err
+}
@@ -3,50 +3,154 @@ package database
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/rs/zerolog/log"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgtype/zeronull"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mi-raf/cars-catalog/internal"
 	mod "github.com/mi-raf/cars-catalog/internal/models"
 )
 
 const (
-	delete              = "DELETE FROM Car WHERE reg_num = $1"
-	searchRegNum        = "SELECT reg_num FROM Car WHERE reg_num = $1"
-	selectOwnerID       = "SELECT id_p FROM People WHERE name_p = $1 AND surname_p = $2 AND CASE WHEN patronymic_p IS NULL THEN true ELSE patronymic_p = $3 END"
-	insertOwner         = "INSERT INTO People (name_p, surname_p, patronymic_p) VALUES ($1, $2, $3) RETURNING id_p"
-	insertCar           = "INSERT INTO Car (reg_num, mark, model, year_c, id_p ) VALUES ($1, $2, $3, $4, $5) ON CONFLICT (reg_num) DO NOTHING"
-	searchCarAllWithFil = `
-	SELECT reg_num, mark, model, year_c, p.name_p AS name_p, p.surname_p AS surname_p, p.patronymic_p AS patronymic_p   
+	softDelete         = "UPDATE Car SET deleted_at = now(), version = version + 1 WHERE reg_num = $1 AND deleted_at IS NULL AND ($2::integer IS NULL OR version = $2::integer)"
+	restore            = "UPDATE Car SET deleted_at = NULL, version = version + 1 WHERE reg_num = $1 AND deleted_at IS NOT NULL"
+	purge              = "DELETE FROM Car WHERE reg_num = $1 AND deleted_at < $2"
+	selectPurgeable    = "SELECT reg_num FROM Car WHERE deleted_at < $1 ORDER BY reg_num FOR UPDATE"
+	searchRegNum       = "SELECT reg_num FROM Car WHERE reg_num = $1"
+	searchActiveRegNum = "SELECT reg_num FROM Car WHERE reg_num = $1 AND deleted_at IS NULL"
+	selectOwnerID      = "SELECT id_p FROM People WHERE name_p = $1 AND surname_p = $2 AND CASE WHEN patronymic_p IS NULL THEN true ELSE patronymic_p = $3 END"
+	insertOwner        = "INSERT INTO People (name_p, surname_p, patronymic_p) VALUES ($1, $2, $3) RETURNING id_p"
+	lockCar            = "SELECT reg_num FROM Car WHERE reg_num = $1 AND deleted_at IS NULL FOR UPDATE"
+
+	// a soft deleted car is brought back with the new data when added again
+	insertCar = `INSERT INTO Car (reg_num, mark, model, year_c, id_p, plate_type, region_code, vin, color, body_type, fuel, engine_volume, transmission, mileage)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+	ON CONFLICT (reg_num) DO UPDATE SET
+		mark = EXCLUDED.mark,
+		model = EXCLUDED.model,
+		year_c = EXCLUDED.year_c,
+		id_p = EXCLUDED.id_p,
+		plate_type = EXCLUDED.plate_type,
+		region_code = EXCLUDED.region_code,
+		vin = EXCLUDED.vin,
+		color = EXCLUDED.color,
+		body_type = EXCLUDED.body_type,
+		fuel = EXCLUDED.fuel,
+		engine_volume = EXCLUDED.engine_volume,
+		transmission = EXCLUDED.transmission,
+		mileage = EXCLUDED.mileage,
+		deleted_at = NULL,
+		version = Car.version + 1
+	WHERE Car.deleted_at IS NOT NULL`
+
+	carVINKey       = "car_vin_key"
+	uniqueViolation = "23505"
+
+	carColumns = `
+	reg_num, mark, model, year_c, version, deleted_at, p.name_p AS name_p, p.surname_p AS surname_p, p.patronymic_p AS patronymic_p, plate_type, region_code, vin, id_c,
+	color, body_type, fuel, engine_volume, transmission, mileage, suspicious_rollback, status, status_reason`
+	carFrom = `
 	FROM Car JOIN People AS p
-	ON Car.id_p = p.id_p 
-	   WHERE ($1::varchar IS NULL OR reg_num LIKE CONCAT('%%', $1::varchar, '%%')) AND
-		($2::varchar IS NULL OR mark LIKE CONCAT('%%', $2::varchar, '%%'))  AND 
-		($3::varchar IS NULL OR model LIKE CONCAT('%%', $3::varchar, '%%')) AND
-		($4::integer IS NULL OR year_c = $4::integer) AND
-		($5::varchar IS NULL OR p.name_p LIKE CONCAT('%%', $5::varchar, '%%')) AND
-		($6::varchar IS NULL OR p.surname_p LIKE CONCAT('%%', $6::varchar, '%%')) AND
-		($7::varchar IS NULL OR p.patronymic_p LIKE CONCAT('%%', $7::varchar, '%%'))
+	ON Car.id_p = p.id_p`
+	carFilterCond = `
+	(@reg_num::varchar IS NULL OR reg_num LIKE CONCAT('%%', @reg_num::varchar, '%%')) AND
+		(@mark::varchar IS NULL OR mark LIKE CONCAT('%%', @mark::varchar, '%%'))  AND 
+		(@model::varchar IS NULL OR model LIKE CONCAT('%%', @model::varchar, '%%')) AND
+		(@year::integer IS NULL OR year_c = @year::integer) AND
+		(@name::varchar IS NULL OR p.name_p LIKE CONCAT('%%', @name::varchar, '%%')) AND
+		(@surname::varchar IS NULL OR p.surname_p LIKE CONCAT('%%', @surname::varchar, '%%')) AND
+		(@patronymic::varchar IS NULL OR p.patronymic_p LIKE CONCAT('%%', @patronymic::varchar, '%%')) AND
+		(@region::varchar IS NULL OR region_code = @region::varchar) AND
+		(@vin::varchar IS NULL OR vin = @vin::varchar) AND
+		(@color::varchar IS NULL OR lower(color) = lower(@color::varchar)) AND
+		(@body_type::varchar IS NULL OR body_type = @body_type::varchar) AND
+		(@fuel::varchar IS NULL OR fuel = @fuel::varchar) AND
+		(@transmission::varchar IS NULL OR transmission = @transmission::varchar) AND
+		(@mileage_min::integer IS NULL OR mileage >= @mileage_min::integer) AND
+		(@mileage_max::integer IS NULL OR mileage <= @mileage_max::integer) AND
+		(@engine_volume_min::integer IS NULL OR engine_volume >= @engine_volume_min::integer) AND
+		(@engine_volume_max::integer IS NULL OR engine_volume <= @engine_volume_max::integer) AND
+		(@status::varchar IS NULL OR status = @status::varchar) AND
+		(@include_deleted::boolean OR deleted_at IS NULL)`
+
+	// ORDER BY is filled in by carsQuery
+	searchCarAllWithFil = `
+	SELECT` + carColumns + carFrom + `
+	WHERE` + carFilterCond + `
+	ORDER BY %s
+	LIMIT @limit
+	OFFSET @offset`
+
+	selectCar = `
+	SELECT` + carColumns + carFrom + `
+	WHERE reg_num = $1 AND deleted_at IS NULL`
+
+	lockRegNumsWithFil = `
+	SELECT reg_num` + carFrom + `
+	WHERE` + carFilterCond + `
 	ORDER BY reg_num
-	LIMIT $8
-	OFFSET $9`
+	LIMIT @limit
+	FOR UPDATE OF Car`
 
 	update = `UPDATE Car SET 
     			mark = COALESCE($2, mark),
     			model = COALESCE($3, model),
     			year_c = COALESCE($4, year_c),
-    			id_p = COALESCE($5, id_p)
-			WHERE reg_num = $1`
+    			id_p = COALESCE($5, id_p),
+    			vin = COALESCE($7, vin),
+    			color = COALESCE($8, color),
+    			body_type = COALESCE($9, body_type),
+    			fuel = COALESCE($10, fuel),
+    			engine_volume = COALESCE($11, engine_volume),
+    			transmission = COALESCE($12, transmission),
+    			mileage = COALESCE($13, mileage),
+    			version = version + 1
+			WHERE reg_num = $1 AND deleted_at IS NULL AND ($6::integer IS NULL OR version = $6::integer)`
 )
 
 type (
 	CarRepository interface {
-		Delete(ctx context.Context, regNum string) error
+		Delete(ctx context.Context, regNum string, version int32) error
+		Restore(ctx context.Context, regNum string) error
+		Purge(ctx context.Context, before time.Time) (int64, error)
 		Add(ctx context.Context, cars []mod.CarDTO) error
+		Get(ctx context.Context, regNum string) (*mod.CarDTO, error)
+		GetAsOf(ctx context.Context, regNum string, asOf time.Time) (*mod.CarDTO, error)
 		GetAll(ctx context.Context, filter mod.CarFilter, offset, limit int) ([]mod.CarDTO, error)
+		ForEach(ctx context.Context, filter mod.CarFilter, fn func(*mod.CarDTO) error) error
+		Import(ctx context.Context, cars []mod.CarDTO, dryRun bool) ([]string, error)
+		Stats(ctx context.Context, filter mod.CarFilter, q mod.StatsQuery) (mod.Stats, error)
+		GetAllWithFacets(ctx context.Context, filter mod.CarFilter, offset, limit int, facets []string) ([]mod.CarDTO, map[string][]mod.StatGroup, error)
+		SuggestMarks(ctx context.Context, prefix string, limit int) ([]mod.StatGroup, error)
+		SuggestModels(ctx context.Context, mark, prefix string, limit int) ([]mod.StatGroup, error)
+		SuggestOwners(ctx context.Context, prefix string, limit int) ([]mod.StatGroup, error)
+		Search(ctx context.Context, query string, offset, limit int) ([]mod.SearchHit, error)
 		Update(ctx context.Context, car *mod.CarDTO) error
+		History(ctx context.Context, regNum string) ([]mod.AuditRecord, error)
+		AuditSince(ctx context.Context, since time.Time, limit int) ([]mod.AuditRecord, error)
+		Transfer(ctx context.Context, regNum string, owner *mod.PeopleDTO, version int32) error
+		Replate(ctx context.Context, req mod.Replate) error
+		Plates(ctx context.Context, regNum string) ([]mod.PlateChange, error)
+		CurrentPlate(ctx context.Context, regNum string) (string, error)
+		ChangeStatus(ctx context.Context, req mod.StatusChange) error
+		Owners(ctx context.Context, regNum string) ([]mod.Ownership, error)
+		AddMileage(ctx context.Context, regNum string, reading mod.MileageReading, override bool) error
+		Mileage(ctx context.Context, regNum string) ([]mod.MileageReading, error)
+		AddMaintenance(ctx context.Context, regNum string, m *mod.Maintenance) error
+		GetMaintenance(ctx context.Context, regNum string, id int64) (*mod.Maintenance, error)
+		ListMaintenance(ctx context.Context, regNum string, filter mod.MaintenanceFilter) ([]mod.Maintenance, error)
+		UpdateMaintenance(ctx context.Context, regNum string, m *mod.Maintenance) error
+		DeleteMaintenance(ctx context.Context, regNum string, id int64) error
+		LastServices(ctx context.Context, carIDs []int64) (map[int64]time.Time, error)
+		DeleteBatch(ctx context.Context, req mod.BatchDelete) ([]mod.DeleteResult, error)
 	}
 
 	PgCarRepository struct {
@@ -78,28 +182,28 @@ func (r *PgCarRepository) Add(ctx context.Context, cars []mod.CarDTO) error {
 		}
 	}()
 
-	var owner *mod.PeopleDTO
 	var ownerID int64
 
 	for _, c := range cars {
 		log.Debug().Interface("car", c).Msg("adding car")
-		owner = c.Owner
-		log.Debug().Interface("owner", owner).Msg("adding car owner")
-		err = tx.QueryRow(ctx, selectOwnerID, owner.Name, owner.Surname, zeronull.Text(owner.Patronymic)).Scan(&ownerID)
-		if err == pgx.ErrNoRows {
-			log.Debug().Int64("owner id", ownerID).Msg("inserting new people")
-			err = tx.QueryRow(ctx, insertOwner, owner.Name, owner.Surname, zeronull.Text(owner.Patronymic)).Scan(&ownerID)
-		}
+		log.Debug().Interface("owner", c.Owner).Msg("adding car owner")
+		ownerID, err = findOrCreateOwner(ctx, tx, c.Owner)
 		if err != nil {
 			log.Error().Err(err).Msg("error insert received")
 			return err
 		}
 
-		_, err = tx.Exec(ctx, insertCar, c.RegNum, c.Mark, c.Model, zeronull.Int4(c.Year), ownerID)
+		affected, err := mutateCar(ctx, tx, c.RegNum, auditActionCreate, insertCar, c.RegNum, c.Mark, c.Model, zeronull.Int4(c.Year), ownerID, zeronull.Text(c.PlateType), zeronull.Text(c.RegionCode), zeronull.Text(c.VIN),
+			zeronull.Text(c.Color), zeronull.Text(c.BodyType), zeronull.Text(c.Fuel), zeronull.Int4(c.EngineVolume), zeronull.Text(c.Transmission), zeronull.Int4(c.Mileage))
 
 		if err != nil {
 			log.Error().Str("car's reg num", c.RegNum).Msg("can't insert car")
-			return err
+			return vinConflict(err)
+		}
+		if affected > 0 {
+			if err = changeOwnership(ctx, tx, c.RegNum, ownerID); err != nil {
+				return err
+			}
 		}
 		log.Debug().Str("car's reg num", c.RegNum).Msg("car insert to table")
 
@@ -107,23 +211,332 @@ func (r *PgCarRepository) Add(ctx context.Context, cars []mod.CarDTO) error {
 	return tx.Commit(ctx)
 }
 
-func (r *PgCarRepository) Delete(ctx context.Context, regNum string) error {
+// Delete marks the car as deleted; the row is removed by Purge once the
+// retention is over. A non-zero version makes the delete conditional: internal.ErrVersionMismatch
+// is returned if the stored version differs.
+func (r *PgCarRepository) Delete(ctx context.Context, regNum string, version int32) error {
 	if len(regNum) < 1 {
 		log.Error().Msg("registration number is empty")
 		return errors.New("regNum is empty")
 	}
-	log.Debug().Str("reg num", regNum).Msg("try delete")
-	_, err := r.pool.Exec(ctx, delete, regNum)
+	log.Debug().Str("reg num", regNum).Int32("version", version).Msg("try delete")
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("can't open transaction for delete")
+		return err
+	}
+
+	defer func() {
+		err = tx.Rollback(ctx)
+		if !errors.Is(err, pgx.ErrTxClosed) {
+			log.Error().Err(err).Msg("Undefinded error in tx")
+		}
+	}()
+
+	affected, err := mutateCar(ctx, tx, regNum, auditActionDelete, softDelete, regNum, zeronull.Int4(version))
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return missingCarError(tx.QueryRow(ctx, searchActiveRegNum, regNum))
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *PgCarRepository) Restore(ctx context.Context, regNum string) error {
+	log.Debug().Str("reg num", regNum).Msg("try restore")
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("can't open transaction for restore")
+		return err
+	}
+
+	defer func() {
+		err = tx.Rollback(ctx)
+		if !errors.Is(err, pgx.ErrTxClosed) {
+			log.Error().Err(err).Msg("Undefinded error in tx")
+		}
+	}()
+
+	affected, err := mutateCar(ctx, tx, regNum, auditActionRestore, restore, regNum)
+	if err != nil {
+		log.Error().Err(err).Str("reg num", regNum).Msg("can't restore car")
+		return err
+	}
+	if affected > 0 {
+		return tx.Commit(ctx)
+	}
+
+	var found string
+	err = tx.QueryRow(ctx, searchRegNum, regNum).Scan(&found)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return internal.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return internal.ErrNotDeleted
+}
+
+// Purge removes cars soft deleted before the given time.
+func (r *PgCarRepository) Purge(ctx context.Context, before time.Time) (int64, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("can't open transaction for purge")
+		return 0, err
+	}
+
+	defer func() {
+		err = tx.Rollback(ctx)
+		if !errors.Is(err, pgx.ErrTxClosed) {
+			log.Error().Err(err).Msg("Undefinded error in tx")
+		}
+	}()
+
+	rows, err := tx.Query(ctx, selectPurgeable, before)
+	if err != nil {
+		log.Error().Err(err).Time("before", before).Msg("can't select cars for purge")
+		return 0, err
+	}
+	regNums, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		log.Error().Err(err).Time("before", before).Msg("can't read cars for purge")
+		return 0, err
+	}
+
+	var purged int64
+	for _, regNum := range regNums {
+		affected, err := mutateCar(ctx, tx, regNum, auditActionPurge, purge, regNum, before)
+		if err != nil {
+			log.Error().Err(err).Str("reg num", regNum).Msg("can't purge car")
+			return 0, err
+		}
+		purged += affected
+	}
+	log.Debug().Int64("cars", purged).Time("before", before).Msg("purge cars")
+	return purged, tx.Commit(ctx)
+}
+
+func (r *PgCarRepository) Get(ctx context.Context, regNum string) (*mod.CarDTO, error) {
+	c, err := scanCar(r.pool.QueryRow(ctx, selectCar, regNum))
+	if errors.Is(err, pgx.ErrNoRows) {
+		log.Debug().Str("reg num", regNum).Msg("car not found")
+		return nil, internal.ErrNotFound
+	}
+	if err != nil {
+		log.Error().Err(err).Str("reg num", regNum).Msg("can't get car")
+		return nil, err
+	}
+	return &c, nil
+}
+
+// DeleteBatch deletes the listed cars, or all cars matching the filter when it
+// is not nil, in a single transaction. In dry-run mode the affected cars are
+// locked and reported but the transaction is rolled back. A filter matching
+// more than req.MaxMatches cars deletes nothing and gives
+// internal.ErrTooManyMatches.
+func (r *PgCarRepository) DeleteBatch(ctx context.Context, req mod.BatchDelete) ([]mod.DeleteResult, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("can't open transaction for batch delete")
+		return nil, err
+	}
+
+	defer func() {
+		err = tx.Rollback(ctx)
+		if !errors.Is(err, pgx.ErrTxClosed) {
+			log.Error().Err(err).Msg("Undefinded error in tx")
+		}
+	}()
+
+	regNums := req.RegNums
+	if req.Filter != nil {
+		args := filterArgs(*req.Filter)
+		// one car over the limit is enough to refuse
+		args["limit"] = zeronull.Int8(0)
+		if req.MaxMatches > 0 {
+			args["limit"] = zeronull.Int8(req.MaxMatches + 1)
+		}
+		rows, err := tx.Query(ctx, lockRegNumsWithFil, args)
+		if err != nil {
+			log.Error().Err(err).Msg("can't select cars for batch delete")
+			return nil, err
+		}
+		regNums, err = pgx.CollectRows(rows, pgx.RowTo[string])
+		if err != nil {
+			log.Error().Err(err).Msg("can't read cars for batch delete")
+			return nil, err
+		}
+		if req.MaxMatches > 0 && len(regNums) > req.MaxMatches {
+			log.Debug().Int("max", req.MaxMatches).Msg("filter matches too many cars for batch delete")
+			return nil, fmt.Errorf("%w: the filter matches more than %d cars", internal.ErrTooManyMatches, req.MaxMatches)
+		}
+	}
+
+	results := make([]mod.DeleteResult, 0, len(regNums))
+	seen := make(map[string]bool, len(regNums))
+	for _, regNum := range regNums {
+		if seen[regNum] {
+			continue
+		}
+		seen[regNum] = true
+
+		var affected int64
+		if req.DryRun {
+			var locked string
+			err = tx.QueryRow(ctx, lockCar, regNum).Scan(&locked)
+			if err == nil {
+				affected = 1
+			}
+			if errors.Is(err, pgx.ErrNoRows) {
+				err = nil
+			}
+		} else {
+			affected, err = mutateCar(ctx, tx, regNum, auditActionDelete, softDelete, regNum, nil)
+		}
+		if err != nil {
+			log.Error().Err(err).Str("reg num", regNum).Msg("can't delete car in batch")
+			return nil, err
+		}
+
+		status := mod.DeleteStatusNotFound
+		if affected > 0 {
+			status = mod.DeleteStatusDeleted
+		}
+		results = append(results, mod.DeleteResult{RegNum: regNum, Status: status})
+	}
+
+	if req.DryRun {
+		log.Debug().Int("cars", len(results)).Msg("batch delete dry run")
+		return results, nil
+	}
+	log.Debug().Int("cars", len(results)).Msg("batch delete")
+	return results, tx.Commit(ctx)
+}
+
+// findOrCreateOwner returns the id of the person, inserting a new one if needed.
+func findOrCreateOwner(ctx context.Context, tx pgx.Tx, owner *mod.PeopleDTO) (int64, error) {
+	var ownerID int64
+	err := tx.QueryRow(ctx, selectOwnerID, owner.Name, owner.Surname, zeronull.Text(owner.Patronymic)).Scan(&ownerID)
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return ownerID, err
+	}
+
+	log.Debug().Interface("owner", owner).Msg("inserting new people")
+	err = tx.QueryRow(ctx, insertOwner, owner.Name, owner.Surname, zeronull.Text(owner.Patronymic)).Scan(&ownerID)
+	if err != nil {
+		return 0, err
+	}
+	created := *owner
+	created.Id = ownerID
+	return ownerID, writeAudit(ctx, tx, auditEntityPeople, strconv.FormatInt(ownerID, 10), auditActionCreate, nil, newPeopleSnapshot(&created))
+}
+
+// filteredCars returns the FROM and WHERE clauses selecting the cars matching
+// the filter, from history when the filter is temporal, with their arguments.
+func filteredCars(filter mod.CarFilter) (string, pgx.NamedArgs) {
+	args := filterArgs(filter)
+	if filter.AsOf.IsZero() {
+		return carFrom + `
+	WHERE` + carFilterCond, args
+	}
+	args["as_of"] = filter.AsOf
+	return carHistoryFrom + `
+	WHERE` + carAsOfCond + carFilterCond, args
+}
 
+func filterArgs(filter mod.CarFilter) pgx.NamedArgs {
+	return pgx.NamedArgs{
+		"reg_num":           zeronull.Text(filter.RegNum),
+		"mark":              zeronull.Text(filter.Mark),
+		"model":             zeronull.Text(filter.Model),
+		"year":              zeronull.Int4(filter.Year),
+		"name":              zeronull.Text(filter.Name),
+		"surname":           zeronull.Text(filter.Surname),
+		"patronymic":        zeronull.Text(filter.Patronymic),
+		"region":            zeronull.Text(filter.Region),
+		"vin":               zeronull.Text(filter.VIN),
+		"color":             zeronull.Text(filter.Color),
+		"body_type":         zeronull.Text(filter.BodyType),
+		"fuel":              zeronull.Text(filter.Fuel),
+		"transmission":      zeronull.Text(filter.Transmission),
+		"mileage_min":       zeronull.Int4(filter.MileageMin),
+		"mileage_max":       zeronull.Int4(filter.MileageMax),
+		"engine_volume_min": zeronull.Int4(filter.EngineVolumeMin),
+		"engine_volume_max": zeronull.Int4(filter.EngineVolumeMax),
+		"status":            zeronull.Text(filter.Status),
+		"include_deleted":   filter.IncludeDeleted,
+	}
+}
+
+// vinConflict reports a VIN already taken by another car as internal.ErrDuplicateVIN.
+func vinConflict(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation && pgErr.ConstraintName == carVINKey {
+		return internal.ErrDuplicateVIN
+	}
 	return err
 }
 
+// missingCarError explains why a conditional statement affected no rows:
+// either the car does not exist or its version has moved on.
+func missingCarError(row pgx.Row) error {
+	var regNum string
+	err := row.Scan(&regNum)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return internal.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return internal.ErrVersionMismatch
+}
+
+// scanCar reads carColumns; extra destinations receive the columns selected after them.
+func scanCar(row pgx.Row, extra ...any) (mod.CarDTO, error) {
+	c := mod.CarDTO{}
+	owner := mod.PeopleDTO{}
+	var yz zeronull.Int2
+	var p zeronull.Text
+	var d zeronull.Timestamptz
+	var pt, rc, vin zeronull.Text
+	var id zeronull.Int8
+	var color, body, fuel, trans zeronull.Text
+	var engine, mileage zeronull.Int4
+	// versions kept in Car_history before the columns were added have no value
+	var rollback pgtype.Bool
+	var status, reason zeronull.Text
+	dest := append([]any{&c.RegNum, &c.Mark, &c.Model, &yz, &c.Version, &d, &owner.Name, &owner.Surname, &p, &pt, &rc, &vin, &id,
+		&color, &body, &fuel, &engine, &trans, &mileage, &rollback, &status, &reason}, extra...)
+	err := row.Scan(dest...)
+	owner.Patronymic = string(p)
+	c.PlateType = string(pt)
+	c.RegionCode = string(rc)
+	c.VIN = string(vin)
+	c.ID = int64(id)
+	c.Color = string(color)
+	c.BodyType = string(body)
+	c.Fuel = string(fuel)
+	c.EngineVolume = int32(engine)
+	c.Transmission = string(trans)
+	c.Mileage = int32(mileage)
+	c.SuspiciousRollback = rollback.Bool
+	c.Status = string(status)
+	if len(c.Status) < 1 {
+		c.Status = mod.StatusActive
+	}
+	c.StatusReason = string(reason)
+	c.DeletedAt = time.Time(d)
+	c.Year = int32(yz)
+	c.Owner = &owner
+	return c, err
+}
+
+// GetAll returns a page of cars matching the filter. When filter.AsOf is set
+// the cars are read from the history as they were at that instant.
 func (r *PgCarRepository) GetAll(ctx context.Context, filter mod.CarFilter, offset, limit int) ([]mod.CarDTO, error) {
 
-	rows, err := r.pool.Query(ctx, searchCarAllWithFil, zeronull.Text(filter.RegNum),
-		zeronull.Text(filter.Mark), zeronull.Text(filter.Model), zeronull.Int4(filter.Year),
-		zeronull.Text(filter.Name), zeronull.Text(filter.Surname), zeronull.Text(filter.Patronymic),
-		zeronull.Int4(limit), zeronull.Int4(offset))
+	rows, err := r.queryCars(ctx, filter, offset, limit)
 	if err == pgx.ErrNoRows {
 		log.Debug().Msg("GetAll return 0 rows")
 		return []mod.CarDTO{}, nil
@@ -136,17 +549,10 @@ func (r *PgCarRepository) GetAll(ctx context.Context, filter mod.CarFilter, offs
 	cars := make([]mod.CarDTO, 0)
 
 	for rows.Next() {
-		c := mod.CarDTO{}
-		owner := mod.PeopleDTO{}
-		var yz zeronull.Int2
-		var p zeronull.Text
-		err = rows.Scan(&c.RegNum, &c.Mark, &c.Model, &yz, &owner.Name, &owner.Surname, &p)
-		owner.Patronymic = string(p)
-		c.Year = int32(yz)
+		c, err := scanCar(rows)
 		if err != nil {
 			return nil, err
 		}
-		c.Owner = &owner
 		cars = append(cars, c)
 		log.Debug().Interface("car", c).Msg("get car with filter")
 	}
@@ -159,6 +565,90 @@ func (r *PgCarRepository) GetAll(ctx context.Context, filter mod.CarFilter, offs
 	return cars, nil
 }
 
+// ForEach streams every car matching the filter to fn without paging. The
+// iteration stops at the first error returned by fn.
+func (r *PgCarRepository) ForEach(ctx context.Context, filter mod.CarFilter, fn func(*mod.CarDTO) error) error {
+	rows, err := r.queryCars(ctx, filter, 0, 0)
+	if err != nil {
+		log.Error().Err(err).Msg("can't query cars for iteration")
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		c, err := scanCar(rows)
+		if err != nil {
+			return err
+		}
+		if err = fn(&c); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// queryCars selects cars matching the filter; zero limit and offset select all rows.
+func (r *PgCarRepository) queryCars(ctx context.Context, filter mod.CarFilter, offset, limit int) (pgx.Rows, error) {
+	q, args, err := carsQuery(filter, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	return r.pool.Query(ctx, q, args)
+}
+
+func carsQuery(filter mod.CarFilter, offset, limit int) (string, pgx.NamedArgs, error) {
+	order, err := orderBy(filter.Sort)
+	if err != nil {
+		return "", nil, err
+	}
+	args := filterArgs(filter)
+	args["limit"] = zeronull.Int4(limit)
+	args["offset"] = zeronull.Int4(offset)
+	q := searchCarAllWithFil
+	if !filter.AsOf.IsZero() {
+		q = searchCarAsOfWithFil
+		args["as_of"] = filter.AsOf
+	}
+	return fmt.Sprintf(q, order), args, nil
+}
+
+// sortColumns maps sort keys to the columns they order by.
+var sortColumns = map[string]string{
+	mod.SortRegNum:       "reg_num",
+	mod.SortMark:         "mark",
+	mod.SortModel:        "model",
+	mod.SortYear:         "year_c",
+	mod.SortColor:        "color",
+	mod.SortBodyType:     "body_type",
+	mod.SortFuel:         "fuel",
+	mod.SortEngineVolume: "engine_volume",
+	mod.SortTransmission: "transmission",
+	mod.SortMileage:      "mileage",
+}
+
+// orderBy turns a sort list like "-mileage,year" into an ORDER BY list. Cars
+// without the value come last either way, and reg_num breaks ties so that
+// pages do not overlap.
+func orderBy(sort string) (string, error) {
+	if len(sort) < 1 {
+		return "reg_num", nil
+	}
+	var cols []string
+	for _, key := range strings.Split(sort, ",") {
+		key = strings.TrimSpace(key)
+		dir := "ASC"
+		if strings.HasPrefix(key, "-") {
+			key, dir = key[1:], "DESC"
+		}
+		col, ok := sortColumns[key]
+		if !ok {
+			return "", fmt.Errorf("%w: %s", internal.ErrUnknownSort, key)
+		}
+		cols = append(cols, col+" "+dir+" NULLS LAST")
+	}
+	return strings.Join(append(cols, "reg_num"), ", "), nil
+}
+
 func (r *PgCarRepository) Update(ctx context.Context, car *mod.CarDTO) error {
 	tx, err := r.pool.Begin(ctx)
 	if err != nil {
@@ -173,14 +663,12 @@ func (r *PgCarRepository) Update(ctx context.Context, car *mod.CarDTO) error {
 		}
 	}()
 
+	if err = checkWritable(ctx, tx, car.RegNum, writeUpdate); err != nil {
+		return err
+	}
 	var ownerID int64
 	if len(car.Owner.Name) > 1 && len(car.Owner.Surname) > 1 {
-		owner := *car.Owner
-		err = tx.QueryRow(ctx, selectOwnerID, owner.Name, owner.Surname, owner.Patronymic).Scan(&ownerID)
-		if err == pgx.ErrNoRows {
-			err = tx.QueryRow(ctx, insertOwner, owner.Name, owner.Surname, zeronull.Text(owner.Patronymic)).Scan(&ownerID)
-			log.Debug().Interface("owner", owner).Msg("create new owner for update")
-		}
+		ownerID, err = findOrCreateOwner(ctx, tx, car.Owner)
 	} else {
 		if len(car.Owner.Name) > 1 || len(car.Owner.Surname) > 1 {
 			log.Error().Msg("add new name and surname")
@@ -193,10 +681,35 @@ func (r *PgCarRepository) Update(ctx context.Context, car *mod.CarDTO) error {
 		return err
 	}
 
-	_, err = tx.Exec(ctx, update, car.RegNum, zeronull.Text(car.Mark), zeronull.Text(car.Model), zeronull.Int4(car.Year), zeronull.Int8(ownerID))
+	// mileage is an odometer reading taken today and obeys the same order
+	// check as the mileage log, without an override; the car takes it in the
+	// same update unless a later reading is logged
+	var mileage int32
+	if car.Mileage > 0 {
+		reading := mod.MileageReading{Mileage: car.Mileage, ReadOn: time.Now().UTC()}
+		latest, _, err := logMileage(ctx, tx, car.RegNum, reading, false)
+		if err != nil {
+			return err
+		}
+		if latest {
+			mileage = car.Mileage
+		}
+	}
+
+	affected, err := mutateCar(ctx, tx, car.RegNum, auditActionUpdate, update, car.RegNum, zeronull.Text(car.Mark), zeronull.Text(car.Model), zeronull.Int4(car.Year), zeronull.Int8(ownerID), zeronull.Int4(car.Version), zeronull.Text(car.VIN),
+		zeronull.Text(car.Color), zeronull.Text(car.BodyType), zeronull.Text(car.Fuel), zeronull.Int4(car.EngineVolume), zeronull.Text(car.Transmission), zeronull.Int4(mileage))
 	if err != nil {
 		log.Error().Err(err).Str("Reg num", car.RegNum).Msg("can't update car")
-		return err
+		return vinConflict(err)
+	}
+	if affected == 0 {
+		log.Debug().Str("reg num", car.RegNum).Int32("version", car.Version).Msg("car not updated")
+		return missingCarError(tx.QueryRow(ctx, searchActiveRegNum, car.RegNum))
+	}
+	if ownerID != 0 {
+		if err = changeOwnership(ctx, tx, car.RegNum, ownerID); err != nil {
+			return err
+		}
 	}
 	log.Debug().Str("reg num", car.RegNum).Msg("update car")
 	return tx.Commit(ctx)
@@ -2,6 +2,8 @@ package database_test
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
@@ -9,6 +11,8 @@ import (
 
 	//"github.com/go-delve/delve/pkg/dwarf/regnum"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mi-raf/cars-catalog/internal"
+	"github.com/mi-raf/cars-catalog/internal/audit"
 	"github.com/mi-raf/cars-catalog/internal/database"
 
 	mod "github.com/mi-raf/cars-catalog/internal/models"
@@ -79,7 +83,7 @@ func (s *RepositoryTestSuite) TestGetAll() {
 
 func (s *RepositoryTestSuite) TestGetAllWithFilter() {
 	filter := mod.CarFilter{
-		RegNum: "aa000a00",
+		RegNum: "AA000A00",
 	}
 	cars, err := s.r.GetAll(s.ctx, filter, 0, 10)
 	s.NoError(err)
@@ -87,6 +91,27 @@ func (s *RepositoryTestSuite) TestGetAllWithFilter() {
 	s.Equal(1, len(cars))
 }
 
+func (s *RepositoryTestSuite) TestForEach() {
+	var regNums []string
+	err := s.r.ForEach(s.ctx, mod.CarFilter{Mark: "hot"}, func(c *mod.CarDTO) error {
+		regNums = append(regNums, c.RegNum)
+		return nil
+	})
+	s.NoError(err)
+	s.ElementsMatch([]string{"RT123RT00", "AA000A00"}, regNums)
+}
+
+func (s *RepositoryTestSuite) TestForEachStopsOnError() {
+	stop := errors.New("stop")
+	calls := 0
+	err := s.r.ForEach(s.ctx, mod.CarFilter{}, func(c *mod.CarDTO) error {
+		calls++
+		return stop
+	})
+	s.ErrorIs(err, stop)
+	s.Equal(1, calls)
+}
+
 func (s *RepositoryTestSuite) TestCreateCar() {
 	//given
 	expCar := &mod.CarDTO{
@@ -108,6 +133,300 @@ func (s *RepositoryTestSuite) TestCreateCar() {
 
 }
 
+func (s *RepositoryTestSuite) TestCreateCarWithPlateType() {
+	//given
+	expCar := mod.CarDTO{
+		RegNum:     "CC337E150",
+		Mark:       "BMW",
+		Model:      "21trw",
+		Year:       2020,
+		PlateType:  "civil",
+		RegionCode: "150",
+		Owner: &mod.PeopleDTO{
+			Name:    "Fil",
+			Surname: "Foo",
+		}}
+	//when
+	err := s.r.Add(s.ctx, []mod.CarDTO{expCar})
+	//then
+	s.Require().NoError(err)
+	actCar, err := s.r.Get(s.ctx, expCar.RegNum)
+	s.Require().NoError(err)
+	s.Equal("civil", actCar.PlateType)
+	s.Equal("150", actCar.RegionCode)
+}
+
+func (s *RepositoryTestSuite) TestCreateCarWithVIN() {
+	//given
+	owner := &mod.PeopleDTO{Name: "Fil", Surname: "Foo"}
+	expCar := mod.CarDTO{RegNum: "CC338E150", Mark: "BMW", Model: "21trw", VIN: "1M8GDM9AXKP042788", Owner: owner}
+	//when
+	err := s.r.Add(s.ctx, []mod.CarDTO{expCar})
+	//then
+	s.Require().NoError(err)
+	cars, err := s.r.GetAll(s.ctx, mod.CarFilter{VIN: expCar.VIN}, 0, 10)
+	s.NoError(err)
+	s.Require().Len(cars, 1)
+	s.Equal(expCar.RegNum, cars[0].RegNum)
+	s.Equal(expCar.VIN, cars[0].VIN)
+
+	err = s.r.Add(s.ctx, []mod.CarDTO{{RegNum: "CC339E150", Mark: "BMW", Model: "21trw", VIN: expCar.VIN, Owner: owner}})
+	s.ErrorIs(err, internal.ErrDuplicateVIN)
+	err = s.r.Update(s.ctx, &mod.CarDTO{RegNum: "AA000A00", VIN: expCar.VIN, Owner: &mod.PeopleDTO{}})
+	s.ErrorIs(err, internal.ErrDuplicateVIN)
+}
+
+func (s *RepositoryTestSuite) TestFilterAndSortByAttributes() {
+	//given
+	owner := &mod.PeopleDTO{Name: "Fil", Surname: "Foo"}
+	cars := []mod.CarDTO{
+		{RegNum: "CC340E150", Mark: "Lada", Model: "Vesta", Color: "White", Fuel: "petrol", EngineVolume: 1596, Mileage: 42000, Owner: owner},
+		{RegNum: "CC341E150", Mark: "Lada", Model: "Vesta", Color: "white", Fuel: "petrol", EngineVolume: 1774, Mileage: 120000, Owner: owner},
+		{RegNum: "CC342E150", Mark: "Tesla", Model: "3", Color: "white", Fuel: "electric", Mileage: 8000, Owner: owner},
+	}
+	s.Require().NoError(s.r.Add(s.ctx, cars))
+	//when
+	res, err := s.r.GetAll(s.ctx, mod.CarFilter{Color: "WHITE", Fuel: "petrol", Sort: "-" + mod.SortMileage}, 0, 10)
+	//then
+	s.Require().NoError(err)
+	s.Require().Len(res, 2)
+	s.Equal("CC341E150", res[0].RegNum)
+	s.Equal(int32(120000), res[0].Mileage)
+	s.Equal("CC340E150", res[1].RegNum)
+
+	res, err = s.r.GetAll(s.ctx, mod.CarFilter{Color: "white", MileageMax: 50000, EngineVolumeMin: 1000}, 0, 10)
+	s.Require().NoError(err)
+	s.Require().Len(res, 1)
+	s.Equal("CC340E150", res[0].RegNum)
+
+	_, err = s.r.GetAll(s.ctx, mod.CarFilter{Sort: "owner"}, 0, 10)
+	s.ErrorIs(err, internal.ErrUnknownSort)
+}
+
+func (s *RepositoryTestSuite) TestMileageLog() {
+	//given
+	regNum := "RT123RT00"
+	day := func(d int) time.Time { return time.Date(2024, time.May, d, 0, 0, 0, 0, time.UTC) }
+	s.Require().NoError(s.r.AddMileage(s.ctx, regNum, mod.MileageReading{Mileage: 10000, ReadOn: day(1)}, false))
+	s.Require().NoError(s.r.AddMileage(s.ctx, regNum, mod.MileageReading{Mileage: 12000, ReadOn: day(10)}, false))
+	//when
+	err := s.r.AddMileage(s.ctx, regNum, mod.MileageReading{Mileage: 9000, ReadOn: day(20)}, false)
+	//then
+	s.ErrorIs(err, internal.ErrMileageRollback)
+	err = s.r.AddMileage(s.ctx, regNum, mod.MileageReading{Mileage: 13000, ReadOn: day(5)}, false)
+	s.ErrorIs(err, internal.ErrMileageRollback)
+	car, err := s.r.Get(s.ctx, regNum)
+	s.Require().NoError(err)
+	s.Equal(int32(12000), car.Mileage)
+	s.False(car.SuspiciousRollback)
+
+	s.Require().NoError(s.r.AddMileage(s.ctx, regNum, mod.MileageReading{Mileage: 9000, ReadOn: day(20)}, true))
+	car, err = s.r.Get(s.ctx, regNum)
+	s.Require().NoError(err)
+	s.Equal(int32(9000), car.Mileage)
+	s.True(car.SuspiciousRollback)
+
+	readings, err := s.r.Mileage(s.ctx, regNum)
+	s.Require().NoError(err)
+	s.Require().Len(readings, 3)
+	s.Equal(int32(10000), readings[0].Mileage)
+	s.False(readings[1].Rollback)
+	s.True(readings[2].Rollback)
+
+	_, err = s.r.Mileage(s.ctx, "NOPE")
+	s.ErrorIs(err, internal.ErrNotFound)
+}
+
+func (s *RepositoryTestSuite) TestUpdateCanNotLowerMileage() {
+	//given
+	regNum := "RT123RT00"
+	s.Require().NoError(s.r.Update(s.ctx, &mod.CarDTO{RegNum: regNum, Mileage: 50000, Owner: &mod.PeopleDTO{}}))
+	//when
+	err := s.r.Update(s.ctx, &mod.CarDTO{RegNum: regNum, Model: "rolled back", Mileage: 20000, Owner: &mod.PeopleDTO{}})
+	//then
+	s.ErrorIs(err, internal.ErrMileageRollback)
+	car, err := s.r.Get(s.ctx, regNum)
+	s.Require().NoError(err)
+	s.Equal(int32(50000), car.Mileage)
+	s.NotEqual("rolled back", car.Model)
+	s.False(car.SuspiciousRollback)
+
+	readings, err := s.r.Mileage(s.ctx, regNum)
+	s.Require().NoError(err)
+	s.Require().Len(readings, 1)
+	s.Equal(int32(50000), readings[0].Mileage)
+}
+
+func (s *RepositoryTestSuite) TestUpdateWithMileageIsOneChange() {
+	//given
+	regNum := "RT123RT00"
+	old, err := s.r.Get(s.ctx, regNum)
+	s.Require().NoError(err)
+	//when
+	s.Require().NoError(s.r.Update(s.ctx, &mod.CarDTO{RegNum: regNum, Model: "cold line", Mileage: 50000, Owner: &mod.PeopleDTO{}}))
+	//then
+	car, err := s.r.Get(s.ctx, regNum)
+	s.Require().NoError(err)
+	s.Equal(old.Version+1, car.Version)
+	s.Equal(int32(50000), car.Mileage)
+	history, err := s.r.History(s.ctx, regNum)
+	s.Require().NoError(err)
+	s.Require().Len(history, 1)
+	s.Equal("update", history[0].Action)
+	readings, err := s.r.Mileage(s.ctx, regNum)
+	s.Require().NoError(err)
+	s.Len(readings, 1)
+}
+
+func (s *RepositoryTestSuite) TestMaintenance() {
+	//given
+	regNum := "RT123RT00"
+	day := func(d int) time.Time { return time.Date(2024, time.May, d, 0, 0, 0, 0, time.UTC) }
+	oil := &mod.Maintenance{DoneOn: day(1), Mileage: 10000, WorkType: "oil_change", Cost: 450000}
+	tires := &mod.Maintenance{DoneOn: day(15), WorkType: "tires", Notes: "summer set"}
+	//when
+	s.Require().NoError(s.r.AddMaintenance(s.ctx, regNum, oil))
+	s.Require().NoError(s.r.AddMaintenance(s.ctx, regNum, tires))
+	//then
+	s.NotZero(oil.ID)
+	got, err := s.r.GetMaintenance(s.ctx, regNum, oil.ID)
+	s.Require().NoError(err)
+	s.Equal(int64(450000), got.Cost)
+	s.Equal("oil_change", got.WorkType)
+
+	records, err := s.r.ListMaintenance(s.ctx, regNum, mod.MaintenanceFilter{From: day(10)})
+	s.Require().NoError(err)
+	s.Require().Len(records, 1)
+	s.Equal(tires.ID, records[0].ID)
+	records, err = s.r.ListMaintenance(s.ctx, regNum, mod.MaintenanceFilter{WorkType: "oil_change"})
+	s.Require().NoError(err)
+	s.Require().Len(records, 1)
+
+	car, err := s.r.Get(s.ctx, regNum)
+	s.Require().NoError(err)
+	last, err := s.r.LastServices(s.ctx, []int64{car.ID})
+	s.Require().NoError(err)
+	s.True(day(15).Equal(last[car.ID]))
+
+	tires.Notes = "winter set"
+	s.Require().NoError(s.r.UpdateMaintenance(s.ctx, regNum, tires))
+	got, err = s.r.GetMaintenance(s.ctx, regNum, tires.ID)
+	s.Require().NoError(err)
+	s.Equal("winter set", got.Notes)
+
+	s.Require().NoError(s.r.DeleteMaintenance(s.ctx, regNum, tires.ID))
+	s.ErrorIs(s.r.DeleteMaintenance(s.ctx, regNum, tires.ID), internal.ErrNoMaintenance)
+	_, err = s.r.GetMaintenance(s.ctx, "NOPE", oil.ID)
+	s.ErrorIs(err, internal.ErrNotFound)
+}
+
+func (s *RepositoryTestSuite) TestChangeStatus() {
+	//given
+	regNum := "RT123RT00"
+	car, err := s.r.Get(s.ctx, regNum)
+	s.Require().NoError(err)
+	s.Equal(mod.StatusActive, car.Status)
+	//when
+	err = s.r.ChangeStatus(s.ctx, mod.StatusChange{RegNum: regNum, From: mod.StatusActive, To: mod.StatusStolen, Reason: "reported by owner"})
+	//then
+	s.Require().NoError(err)
+	cars, err := s.r.GetAll(s.ctx, mod.CarFilter{Status: mod.StatusStolen}, 0, 10)
+	s.Require().NoError(err)
+	s.Require().Len(cars, 1)
+	s.Equal(regNum, cars[0].RegNum)
+	s.Equal("reported by owner", cars[0].StatusReason)
+
+	err = s.r.ChangeStatus(s.ctx, mod.StatusChange{RegNum: regNum, From: mod.StatusActive, To: mod.StatusSold, Reason: "stale"})
+	s.ErrorIs(err, internal.ErrBadTransition)
+	err = s.r.ChangeStatus(s.ctx, mod.StatusChange{RegNum: regNum, From: mod.StatusStolen, To: mod.StatusActive, Reason: "recovered", Version: 1})
+	s.ErrorIs(err, internal.ErrVersionMismatch)
+	err = s.r.ChangeStatus(s.ctx, mod.StatusChange{RegNum: "NOPE", From: mod.StatusActive, To: mod.StatusSold, Reason: "missing"})
+	s.ErrorIs(err, internal.ErrNotFound)
+}
+
+func (s *RepositoryTestSuite) TestFrozenCarsRefuseWrites() {
+	owner := &mod.PeopleDTO{Name: "Ivan", Surname: "Scott"}
+	for _, status := range []string{mod.StatusScrapped, mod.StatusExported} {
+		//given
+		regNum := "RT123RT00"
+		if status == mod.StatusExported {
+			regNum = "AA000A00"
+		}
+		s.Require().NoError(s.r.ChangeStatus(s.ctx, mod.StatusChange{RegNum: regNum, From: mod.StatusActive, To: status}))
+		//then
+		s.ErrorIs(s.r.Update(s.ctx, &mod.CarDTO{RegNum: regNum, Model: "granta", Owner: &mod.PeopleDTO{}}), internal.ErrStatusForbids, status)
+		s.ErrorIs(s.r.Transfer(s.ctx, regNum, owner, 0), internal.ErrStatusForbids, status)
+		s.ErrorIs(s.r.Replate(s.ctx, mod.Replate{RegNum: regNum, NewRegNum: "A002AA77"}), internal.ErrStatusForbids, status)
+		s.ErrorIs(s.r.AddMileage(s.ctx, regNum, mod.MileageReading{Mileage: 1000, ReadOn: time.Now()}, false), internal.ErrStatusForbids, status)
+	}
+}
+
+func (s *RepositoryTestSuite) TestStolenCarKeepsOwnerAndPlates() {
+	//given
+	regNum := "RT123RT00"
+	s.Require().NoError(s.r.ChangeStatus(s.ctx, mod.StatusChange{RegNum: regNum, From: mod.StatusActive, To: mod.StatusStolen}))
+	//then
+	s.ErrorIs(s.r.Transfer(s.ctx, regNum, &mod.PeopleDTO{Name: "Ivan", Surname: "Scott"}, 0), internal.ErrStatusForbids)
+	s.ErrorIs(s.r.Replate(s.ctx, mod.Replate{RegNum: regNum, NewRegNum: "A002AA77"}), internal.ErrStatusForbids)
+	s.NoError(s.r.AddMileage(s.ctx, regNum, mod.MileageReading{Mileage: 1000, ReadOn: time.Now()}, false))
+}
+
+func (s *RepositoryTestSuite) TestReplate() {
+	//given
+	old, err := s.r.Get(s.ctx, "RT123RT00")
+	s.Require().NoError(err)
+	s.Require().NoError(s.r.Update(s.ctx, &mod.CarDTO{RegNum: old.RegNum, Model: "cold line", Owner: &mod.PeopleDTO{}}))
+	//when
+	err = s.r.Replate(s.ctx, mod.Replate{RegNum: old.RegNum, NewRegNum: "A001AA77", PlateType: "civil", RegionCode: "77"})
+	//then
+	s.Require().NoError(err)
+	car, err := s.r.Get(s.ctx, "A001AA77")
+	s.Require().NoError(err)
+	s.Equal(old.ID, car.ID)
+	s.Equal("cold line", car.Model)
+	s.Equal("77", car.RegionCode)
+
+	_, err = s.r.Get(s.ctx, old.RegNum)
+	s.ErrorIs(err, internal.ErrNotFound)
+	current, err := s.r.CurrentPlate(s.ctx, old.RegNum)
+	s.NoError(err)
+	s.Equal("A001AA77", current)
+
+	plates, err := s.r.Plates(s.ctx, "A001AA77")
+	s.NoError(err)
+	s.Require().Len(plates, 1)
+	s.Equal(old.RegNum, plates[0].OldRegNum)
+
+	owners, err := s.r.Owners(s.ctx, "A001AA77")
+	s.NoError(err)
+	s.Len(owners, 1)
+	history, err := s.r.History(s.ctx, "A001AA77")
+	s.NoError(err)
+	s.Require().Len(history, 2)
+	s.Equal("replate", history[1].Action)
+}
+
+func (s *RepositoryTestSuite) TestGetAsOfAcrossReplate() {
+	time.Sleep(10 * time.Millisecond)
+	before := time.Now()
+	time.Sleep(10 * time.Millisecond)
+	s.Require().NoError(s.r.Replate(s.ctx, mod.Replate{RegNum: "RT123RT00", NewRegNum: "A001AA77"}))
+
+	c, err := s.r.GetAsOf(s.ctx, "A001AA77", before)
+	s.Require().NoError(err)
+	s.Equal("RT123RT00", c.RegNum)
+	c, err = s.r.GetAsOf(s.ctx, "RT123RT00", time.Now())
+	s.Require().NoError(err)
+	s.Equal("A001AA77", c.RegNum)
+}
+
+func (s *RepositoryTestSuite) TestReplateToTakenPlate() {
+	err := s.r.Replate(s.ctx, mod.Replate{RegNum: "RT123RT00", NewRegNum: "AA000A00"})
+	s.ErrorIs(err, internal.ErrPlateTaken)
+	err = s.r.Replate(s.ctx, mod.Replate{RegNum: "NOPE", NewRegNum: "A001AA77"})
+	s.ErrorIs(err, internal.ErrNotFound)
+}
+
 func (s *RepositoryTestSuite) TestCreateCarWihtoutPat() {
 	//given
 	expCar := &mod.CarDTO{
@@ -173,7 +492,7 @@ func (s *RepositoryTestSuite) TestCreateCarWihtoutPat2() {
 func (s *RepositoryTestSuite) TestCreateCarDuplicated() {
 	//given
 	expCar := &mod.CarDTO{
-		RegNum: "rt123rt00",
+		RegNum: "RT123RT00",
 		Mark:   "hot",
 		Model:  "hot line",
 		Year:   2020,
@@ -192,10 +511,10 @@ func (s *RepositoryTestSuite) TestCreateCarDuplicated() {
 
 func (s *RepositoryTestSuite) TestDeleteCar() {
 	//given
-	err := s.r.Delete(s.ctx, "rt123rt00")
+	err := s.r.Delete(s.ctx, "RT123RT00", 0)
 	//then
 	s.NoError(err)
-	c, err := s.r.GetAll(s.ctx, mod.CarFilter{RegNum: "rt123rt00"}, 0, 10)
+	c, err := s.r.GetAll(s.ctx, mod.CarFilter{RegNum: "RT123RT00"}, 0, 10)
 	s.NoError(err)
 	s.Len(c, 0)
 }
@@ -206,7 +525,7 @@ func (s *RepositoryTestSuite) TestUpdateCar() {
 		Surname: "Wild",
 	}
 	carNew := mod.CarDTO{
-		RegNum: "rt123rt00",
+		RegNum: "RT123RT00",
 		Mark:   "lada",
 		Model:  "s10",
 		Owner:  &owner,
@@ -218,7 +537,7 @@ func (s *RepositoryTestSuite) TestUpdateCar() {
 func (s *RepositoryTestSuite) TestUpdateCarWithoutOwner() {
 	owner := mod.PeopleDTO{}
 	carNew := mod.CarDTO{
-		RegNum: "rt123rt00",
+		RegNum: "RT123RT00",
 		Mark:   "la",
 		Model:  "s10",
 		Owner:  &owner,
@@ -232,7 +551,7 @@ func (s *RepositoryTestSuite) TestUpdateCarWithoutErrOwner() {
 		Name: "Carl",
 	}
 	carNew := mod.CarDTO{
-		RegNum: "rt123rt00",
+		RegNum: "RT123RT00",
 		Mark:   "la",
 		Model:  "s10",
 		Owner:  &owner,
@@ -241,6 +560,382 @@ func (s *RepositoryTestSuite) TestUpdateCarWithoutErrOwner() {
 	s.Error(err)
 }
 
+func (s *RepositoryTestSuite) TestGetCar() {
+	c, err := s.r.Get(s.ctx, "AA000A00")
+	s.NoError(err)
+	s.Equal("www", c.Model)
+	s.Equal(int32(1), c.Version)
+
+	_, err = s.r.Get(s.ctx, "zz000z00")
+	s.ErrorIs(err, internal.ErrNotFound)
+}
+
+func (s *RepositoryTestSuite) TestUpdateCarWithVersion() {
+	carNew := mod.CarDTO{
+		RegNum:  "AA000A00",
+		Model:   "xxx",
+		Version: 1,
+		Owner:   &mod.PeopleDTO{},
+	}
+	err := s.r.Update(s.ctx, &carNew)
+	s.NoError(err)
+	c, err := s.r.Get(s.ctx, "AA000A00")
+	s.NoError(err)
+	s.Equal(int32(2), c.Version)
+
+	err = s.r.Update(s.ctx, &carNew)
+	s.ErrorIs(err, internal.ErrVersionMismatch)
+}
+
+func (s *RepositoryTestSuite) TestDeleteCarWithStaleVersion() {
+	err := s.r.Delete(s.ctx, "AA000A00", 5)
+	s.ErrorIs(err, internal.ErrVersionMismatch)
+	err = s.r.Delete(s.ctx, "AA000A00", 1)
+	s.NoError(err)
+	err = s.r.Delete(s.ctx, "AA000A00", 0)
+	s.ErrorIs(err, internal.ErrNotFound)
+}
+
+func (s *RepositoryTestSuite) TestDeleteBatch() {
+	res, err := s.r.DeleteBatch(s.ctx, mod.BatchDelete{RegNums: []string{"AA000A00", "zz000z00", "AA000A00"}})
+	s.NoError(err)
+	s.Equal([]mod.DeleteResult{
+		{RegNum: "AA000A00", Status: mod.DeleteStatusDeleted},
+		{RegNum: "zz000z00", Status: mod.DeleteStatusNotFound},
+	}, res)
+	c, err := s.r.GetAll(s.ctx, mod.CarFilter{}, 0, 10)
+	s.NoError(err)
+	s.Len(c, 5)
+}
+
+func (s *RepositoryTestSuite) TestDeleteBatchWithFilterDryRun() {
+	res, err := s.r.DeleteBatch(s.ctx, mod.BatchDelete{Filter: &mod.CarFilter{Mark: "hot"}, DryRun: true})
+	s.NoError(err)
+	s.Len(res, 2)
+	c, err := s.r.GetAll(s.ctx, mod.CarFilter{Mark: "hot"}, 0, 10)
+	s.NoError(err)
+	s.Len(c, 2)
+
+	res, err = s.r.DeleteBatch(s.ctx, mod.BatchDelete{Filter: &mod.CarFilter{Mark: "hot"}})
+	s.NoError(err)
+	s.Len(res, 2)
+	c, err = s.r.GetAll(s.ctx, mod.CarFilter{Mark: "hot"}, 0, 10)
+	s.NoError(err)
+	s.Len(c, 0)
+}
+
+func (s *RepositoryTestSuite) TestDeleteBatchOverMaxMatches() {
+	_, err := s.r.DeleteBatch(s.ctx, mod.BatchDelete{Filter: &mod.CarFilter{Mark: "hot"}, MaxMatches: 1})
+	s.ErrorIs(err, internal.ErrTooManyMatches)
+	c, err := s.r.GetAll(s.ctx, mod.CarFilter{Mark: "hot"}, 0, 10)
+	s.NoError(err)
+	s.Len(c, 2)
+
+	res, err := s.r.DeleteBatch(s.ctx, mod.BatchDelete{Filter: &mod.CarFilter{Mark: "hot"}, MaxMatches: 2})
+	s.NoError(err)
+	s.Len(res, 2)
+}
+
+func (s *RepositoryTestSuite) TestSoftDeleteAndRestore() {
+	err := s.r.Delete(s.ctx, "AA000A00", 0)
+	s.NoError(err)
+	_, err = s.r.Get(s.ctx, "AA000A00")
+	s.ErrorIs(err, internal.ErrNotFound)
+	c, err := s.r.GetAll(s.ctx, mod.CarFilter{RegNum: "AA000A00", IncludeDeleted: true}, 0, 10)
+	s.NoError(err)
+	s.Len(c, 1)
+	s.False(c[0].DeletedAt.IsZero())
+
+	err = s.r.Restore(s.ctx, "AA000A00")
+	s.NoError(err)
+	err = s.r.Restore(s.ctx, "AA000A00")
+	s.ErrorIs(err, internal.ErrNotDeleted)
+	car, err := s.r.Get(s.ctx, "AA000A00")
+	s.NoError(err)
+	s.True(car.DeletedAt.IsZero())
+}
+
+func (s *RepositoryTestSuite) TestPurge() {
+	err := s.r.Delete(s.ctx, "AA000A00", 0)
+	s.NoError(err)
+	n, err := s.r.Purge(s.ctx, time.Now().Add(-time.Hour))
+	s.NoError(err)
+	s.Equal(int64(0), n)
+	n, err = s.r.Purge(s.ctx, time.Now().Add(time.Hour))
+	s.NoError(err)
+	s.Equal(int64(1), n)
+	err = s.r.Restore(s.ctx, "AA000A00")
+	s.ErrorIs(err, internal.ErrNotFound)
+}
+
+func (s *RepositoryTestSuite) TestAuditUpdateAndDelete() {
+	ctx := audit.WithMeta(s.ctx, audit.Meta{Actor: "operator", RequestID: "req-1"})
+	start := time.Now().Add(-time.Minute)
+	err := s.r.Update(ctx, &mod.CarDTO{RegNum: "AA000A00", Model: "yyy", Owner: &mod.PeopleDTO{}})
+	s.NoError(err)
+	err = s.r.Delete(s.ctx, "AA000A00", 0)
+	s.NoError(err)
+
+	h, err := s.r.History(s.ctx, "AA000A00")
+	s.NoError(err)
+	s.Len(h, 2)
+	s.Equal("update", h[0].Action)
+	s.Equal("operator", h[0].Actor)
+	s.Equal("req-1", h[0].RequestID)
+	s.JSONEq(`"www"`, string(mustField(s, h[0].Before, "model")))
+	s.JSONEq(`"yyy"`, string(mustField(s, h[0].After, "model")))
+	s.Equal("delete", h[1].Action)
+	s.Equal(audit.SystemActor, h[1].Actor)
+
+	all, err := s.r.AuditSince(s.ctx, start, 10)
+	s.NoError(err)
+	s.Len(all, 2)
+}
+
+func (s *RepositoryTestSuite) TestAuditCreateWithNewOwner() {
+	err := s.r.Add(s.ctx, []mod.CarDTO{{
+		RegNum: "cc337e10",
+		Mark:   "BMW",
+		Model:  "21trw",
+		Owner:  &mod.PeopleDTO{Name: "New", Surname: "Owner"},
+	}})
+	s.NoError(err)
+
+	all, err := s.r.AuditSince(s.ctx, time.Now().Add(-time.Minute), 10)
+	s.NoError(err)
+	s.Len(all, 2)
+	s.Equal("people", all[0].Entity)
+	s.Equal("car", all[1].Entity)
+	s.Equal("create", all[1].Action)
+	s.Nil(all[1].Before)
+}
+
+func (s *RepositoryTestSuite) TestTransferCar() {
+	err := s.r.Transfer(s.ctx, "AA000A00", &mod.PeopleDTO{Name: "David", Surname: "Scott"}, 1)
+	s.NoError(err)
+	err = s.r.Transfer(s.ctx, "AA000A00", &mod.PeopleDTO{Name: "David", Surname: "Scott"}, 0)
+	s.ErrorIs(err, internal.ErrSameOwner)
+	err = s.r.Transfer(s.ctx, "AA000A00", &mod.PeopleDTO{Name: "Anna", Surname: "Kern"}, 1)
+	s.ErrorIs(err, internal.ErrVersionMismatch)
+	err = s.r.Transfer(s.ctx, "zz000z00", &mod.PeopleDTO{Name: "Anna", Surname: "Kern"}, 0)
+	s.ErrorIs(err, internal.ErrNotFound)
+
+	owners, err := s.r.Owners(s.ctx, "AA000A00")
+	s.NoError(err)
+	s.Len(owners, 2)
+	s.Equal("Ivan", owners[0].Owner.Name)
+	s.False(owners[0].ValidTo.IsZero())
+	s.Equal("David", owners[1].Owner.Name)
+	s.True(owners[1].ValidTo.IsZero())
+
+	c, err := s.r.Get(s.ctx, "AA000A00")
+	s.NoError(err)
+	s.Equal("David", c.Owner.Name)
+}
+
+func (s *RepositoryTestSuite) TestUpdateCarOwnerKeepsHistory() {
+	err := s.r.Update(s.ctx, &mod.CarDTO{RegNum: "RT123RT00", Owner: &mod.PeopleDTO{Name: "Ronald", Surname: "Wild"}})
+	s.NoError(err)
+	owners, err := s.r.Owners(s.ctx, "RT123RT00")
+	s.NoError(err)
+	s.Len(owners, 2)
+	s.Equal("Ronald", owners[1].Owner.Name)
+}
+
+func (s *RepositoryTestSuite) TestGetAsOf() {
+	time.Sleep(10 * time.Millisecond)
+	before := time.Now()
+	time.Sleep(10 * time.Millisecond)
+	err := s.r.Update(s.ctx, &mod.CarDTO{RegNum: "AA000A00", Model: "zzz", Owner: &mod.PeopleDTO{}})
+	s.NoError(err)
+	err = s.r.Delete(s.ctx, "RT123RT00", 0)
+	s.NoError(err)
+
+	c, err := s.r.GetAsOf(s.ctx, "AA000A00", before)
+	s.NoError(err)
+	s.Equal("www", c.Model)
+	c, err = s.r.GetAsOf(s.ctx, "AA000A00", time.Now())
+	s.NoError(err)
+	s.Equal("zzz", c.Model)
+
+	cars, err := s.r.GetAll(s.ctx, mod.CarFilter{AsOf: before}, 0, 10)
+	s.NoError(err)
+	s.Len(cars, 6)
+	cars, err = s.r.GetAll(s.ctx, mod.CarFilter{AsOf: time.Now()}, 0, 10)
+	s.NoError(err)
+	s.Len(cars, 5)
+
+	_, err = s.r.GetAsOf(s.ctx, "AA000A00", before.Add(-time.Hour))
+	s.ErrorIs(err, internal.ErrNotFound)
+}
+
+func mustField(s *RepositoryTestSuite, raw json.RawMessage, name string) json.RawMessage {
+	m := map[string]json.RawMessage{}
+	s.Require().NoError(json.Unmarshal(raw, &m))
+	return m[name]
+}
+
 func TestCustomerRepoTestSuite(t *testing.T) {
 	suite.Run(t, new(RepositoryTestSuite))
 }
+
+func (s *RepositoryTestSuite) TestImport() {
+	imported, err := s.r.Import(s.ctx, []mod.CarDTO{
+		{RegNum: "im001p00", Mark: "Лада", Model: "Веста", Year: 2020, Owner: &mod.PeopleDTO{Name: "Пётр", Surname: "Иванов"}},
+		{RegNum: "im002p00", Mark: "hot", Model: "rod", Owner: &mod.PeopleDTO{Name: "Ivan", Surname: "Scott"}},
+		{RegNum: "AA000A00", Mark: "hot", Model: "other", Owner: &mod.PeopleDTO{Name: "Ivan", Surname: "Scott"}},
+	}, false)
+	s.NoError(err)
+	s.ElementsMatch([]string{"im001p00", "im002p00"}, imported)
+
+	c, err := s.r.Get(s.ctx, "im001p00")
+	s.NoError(err)
+	s.Equal("Веста", c.Model)
+	s.Equal("Иванов", c.Owner.Surname)
+
+	c, err = s.r.Get(s.ctx, "AA000A00")
+	s.NoError(err)
+	s.Equal("www", c.Model)
+
+	owners, err := s.r.Owners(s.ctx, "im002p00")
+	s.NoError(err)
+	s.Len(owners, 1)
+
+	h, err := s.r.History(s.ctx, "im001p00")
+	s.NoError(err)
+	s.Len(h, 1)
+	s.Equal("create", h[0].Action)
+	s.JSONEq(`"Веста"`, string(mustField(s, h[0].After, "model")))
+}
+
+func (s *RepositoryTestSuite) TestImportDryRun() {
+	imported, err := s.r.Import(s.ctx, []mod.CarDTO{
+		{RegNum: "im001p00", Mark: "hot", Model: "rod", Owner: &mod.PeopleDTO{Name: "New", Surname: "Owner"}},
+	}, true)
+	s.NoError(err)
+	s.Equal([]string{"im001p00"}, imported)
+
+	_, err = s.r.Get(s.ctx, "im001p00")
+	s.ErrorIs(err, internal.ErrNotFound)
+	all, err := s.r.AuditSince(s.ctx, time.Now().Add(-time.Minute), 10)
+	s.NoError(err)
+	s.Empty(all)
+}
+
+func (s *RepositoryTestSuite) TestStatsByMark() {
+	stats, err := s.r.Stats(s.ctx, mod.CarFilter{}, mod.StatsQuery{GroupBy: mod.StatsByMark, Top: 2})
+	s.NoError(err)
+	s.Equal(int64(6), stats.Total)
+	s.Len(stats.Groups, 2)
+	s.Equal(mod.StatGroup{Key: "hot", Count: 2}, stats.Groups[0])
+}
+
+func (s *RepositoryTestSuite) TestStatsAndFilterByRegion() {
+	owner := &mod.PeopleDTO{Name: "Fil", Surname: "Foo"}
+	err := s.r.Add(s.ctx, []mod.CarDTO{
+		{RegNum: "X123XX150", Mark: "BMW", Model: "x5", PlateType: "civil", RegionCode: "150", Owner: owner},
+		{RegNum: "A001AA150", Mark: "BMW", Model: "x6", PlateType: "civil", RegionCode: "150", Owner: owner},
+		{RegNum: "AB12323", Mark: "Lada", Model: "Vesta", PlateType: "taxi", RegionCode: "23", Owner: owner},
+	})
+	s.Require().NoError(err)
+
+	stats, err := s.r.Stats(s.ctx, mod.CarFilter{}, mod.StatsQuery{GroupBy: mod.StatsByRegion, Top: 10})
+	s.NoError(err)
+	s.Equal([]mod.StatGroup{
+		{Key: "unknown", Count: 6},
+		{Key: "150", Count: 2},
+		{Key: "23", Count: 1},
+	}, stats.Groups)
+
+	cars, err := s.r.GetAll(s.ctx, mod.CarFilter{Region: "150"}, 0, 10)
+	s.NoError(err)
+	s.Len(cars, 2)
+	s.Equal("A001AA150", cars[0].RegNum)
+}
+
+func (s *RepositoryTestSuite) TestStatsByYearBucket() {
+	stats, err := s.r.Stats(s.ctx, mod.CarFilter{}, mod.StatsQuery{GroupBy: mod.StatsByYear, YearBucket: 10, Top: 10})
+	s.NoError(err)
+	s.Equal([]mod.StatGroup{
+		{Key: "2000-2009", Count: 2},
+		{Key: "2010-2019", Count: 2},
+		{Key: "1990-1999", Count: 1},
+		{Key: "unknown", Count: 1},
+	}, stats.Groups)
+	s.Equal(int64(6), stats.Total)
+}
+
+func (s *RepositoryTestSuite) TestStatsByOwnerWithFilter() {
+	stats, err := s.r.Stats(s.ctx, mod.CarFilter{Surname: "Scott"}, mod.StatsQuery{GroupBy: mod.StatsByOwner, Top: 10})
+	s.NoError(err)
+	s.Equal(int64(4), stats.Total)
+	s.Len(stats.Groups, 2)
+}
+
+func (s *RepositoryTestSuite) TestStatsUnknownGroup() {
+	_, err := s.r.Stats(s.ctx, mod.CarFilter{}, mod.StatsQuery{GroupBy: "color", Top: 10})
+	s.ErrorIs(err, internal.ErrUnknownGroup)
+}
+
+func (s *RepositoryTestSuite) TestGetAllWithFacets() {
+	cars, facets, err := s.r.GetAllWithFacets(s.ctx, mod.CarFilter{Mark: "hot"}, 0, 10, []string{mod.FacetMark, mod.FacetYear})
+	s.NoError(err)
+	s.Len(cars, 2)
+	// the mark facet ignores the mark filter itself
+	s.Len(facets[mod.FacetMark], 5)
+	s.Equal(mod.StatGroup{Key: "hot", Count: 2}, facets[mod.FacetMark][0])
+	s.ElementsMatch([]mod.StatGroup{{Key: "1999", Count: 1}, {Key: "unknown", Count: 1}}, facets[mod.FacetYear])
+}
+
+func (s *RepositoryTestSuite) TestGetAllWithUnknownFacet() {
+	_, _, err := s.r.GetAllWithFacets(s.ctx, mod.CarFilter{}, 0, 10, []string{"color"})
+	s.ErrorIs(err, internal.ErrUnknownGroup)
+}
+
+func (s *RepositoryTestSuite) TestSuggestMarks() {
+	res, err := s.r.SuggestMarks(s.ctx, "H", 10)
+	s.NoError(err)
+	s.Equal([]mod.StatGroup{{Key: "hot", Count: 2}}, res)
+
+	res, err = s.r.SuggestMarks(s.ctx, "%", 10)
+	s.NoError(err)
+	s.Empty(res)
+}
+
+func (s *RepositoryTestSuite) TestSuggestModelsOfMark() {
+	res, err := s.r.SuggestModels(s.ctx, "HOT", "", 10)
+	s.NoError(err)
+	s.ElementsMatch([]mod.StatGroup{{Key: "hot line", Count: 1}, {Key: "www", Count: 1}}, res)
+}
+
+func (s *RepositoryTestSuite) TestSuggestOwners() {
+	res, err := s.r.SuggestOwners(s.ctx, "scott", 10)
+	s.NoError(err)
+	s.Equal([]mod.StatGroup{{Key: "Scott David", Count: 2}, {Key: "Scott Ivan", Count: 2}}, res)
+
+	res, err = s.r.SuggestOwners(s.ctx, "bob sh", 10)
+	s.NoError(err)
+	s.Equal([]mod.StatGroup{{Key: "Shakir Bob Valin", Count: 1}}, res)
+}
+
+func (s *RepositoryTestSuite) TestSearch() {
+	hits, err := s.r.Search(s.ctx, "hot Ivan", 0, 10)
+	s.NoError(err)
+	s.Len(hits, 2)
+	s.Contains(hits[0].Highlight, "<mark>Ivan</mark>")
+
+	hits, err = s.r.Search(s.ctx, "Shakirr", 0, 10)
+	s.NoError(err)
+	s.Len(hits, 1)
+	s.Equal("RT98457RTDS", hits[0].Car.RegNum)
+}
+
+func (s *RepositoryTestSuite) TestSearchPrefixAndEmpty() {
+	hits, err := s.r.Search(s.ctx, "rainGos", 0, 10)
+	s.NoError(err)
+	s.Len(hits, 1)
+	s.Equal("RT666T00", hits[0].Car.RegNum)
+
+	_, err = s.r.Search(s.ctx, "&|!", 0, 10)
+	s.ErrorIs(err, internal.ErrEmptyQuery)
+}
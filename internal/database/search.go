@@ -0,0 +1,85 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"unicode"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/mi-raf/cars-catalog/internal"
+	mod "github.com/mi-raf/cars-catalog/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+// Search documents; they must match the expressions indexed by migration 000008.
+const (
+	carSearchDoc   = `(reg_num || ' ' || mark || ' ' || model)`
+	ownerSearchDoc = `(p.name_p || ' ' || p.surname_p || ' ' || coalesce(p.patronymic_p, ''))`
+
+	carSearchVec   = `to_tsvector('simple', ` + carSearchDoc + `)`
+	ownerSearchVec = `to_tsvector('simple', ` + ownerSearchDoc + `)`
+
+	// a car matches when any word is a prefix of a word of the car or owner
+	// document, or the query is similar to a part of one of them; full text
+	// rank and trigram similarity add up to the relevance
+	searchCars = `
+	SELECT` + carColumns + `, r.rank_s,
+		ts_headline('simple', ` + carSearchDoc + ` || ' ' || ` + ownerSearchDoc + `, q.tsq,
+			'StartSel=<mark>, StopSel=</mark>, HighlightAll=true') AS headline_s` + carFrom + `
+	CROSS JOIN (SELECT to_tsquery('simple', @tsq) AS tsq) AS q
+	CROSS JOIN LATERAL (SELECT
+		ts_rank(` + carSearchVec + ` || ` + ownerSearchVec + `, q.tsq) +
+		greatest(word_similarity(@q, ` + carSearchDoc + `), word_similarity(@q, ` + ownerSearchDoc + `)) AS rank_s
+	) AS r
+	WHERE deleted_at IS NULL AND (
+		` + carSearchVec + ` @@ q.tsq OR
+		` + ownerSearchVec + ` @@ q.tsq OR
+		@q <% ` + carSearchDoc + ` OR
+		@q <% ` + ownerSearchDoc + `)
+	ORDER BY r.rank_s DESC, reg_num
+	LIMIT @limit
+	OFFSET @offset`
+)
+
+// Search looks for cars by free text across registration number, mark, model
+// and owner's names, most relevant first.
+func (r *PgCarRepository) Search(ctx context.Context, query string, offset, limit int) ([]mod.SearchHit, error) {
+	tsq := searchTSQuery(query)
+	if len(tsq) < 1 {
+		return nil, internal.ErrEmptyQuery
+	}
+	rows, err := r.pool.Query(ctx, searchCars, pgx.NamedArgs{
+		"q":      query,
+		"tsq":    tsq,
+		"limit":  limit,
+		"offset": offset,
+	})
+	if err != nil {
+		log.Error().Err(err).Str("query", query).Msg("can't search cars")
+		return nil, err
+	}
+	hits, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (mod.SearchHit, error) {
+		h := mod.SearchHit{}
+		var err error
+		h.Car, err = scanCar(row, &h.Rank, &h.Highlight)
+		return h, err
+	})
+	if err != nil {
+		log.Error().Err(err).Str("query", query).Msg("can't read found cars")
+		return nil, err
+	}
+	return hits, nil
+}
+
+// searchTSQuery builds a to_tsquery expression matching any of the words of
+// the query as a prefix. Everything but letters and digits is dropped, so user
+// input can not break the tsquery syntax.
+func searchTSQuery(query string) string {
+	words := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, w := range words {
+		words[i] = w + ":*"
+	}
+	return strings.Join(words, " | ")
+}
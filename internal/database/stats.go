@@ -0,0 +1,64 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mi-raf/cars-catalog/internal"
+	mod "github.com/mi-raf/cars-catalog/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+// statsKeys maps a group name to the SQL expression of its key.
+var statsKeys = map[string]string{
+	mod.StatsByMark:  "mark",
+	mod.StatsByModel: "concat_ws(' ', mark, model)",
+	mod.StatsByYear: `CASE
+		WHEN year_c IS NULL THEN 'unknown'
+		WHEN @bucket::integer = 1 THEN year_c::text
+		ELSE concat(year_c / @bucket::integer * @bucket::integer, '-', year_c / @bucket::integer * @bucket::integer + @bucket::integer - 1)
+	END`,
+	mod.StatsByOwner:  "concat_ws(' ', p.surname_p, p.name_p, NULLIF(p.patronymic_p, ''))",
+	mod.StatsByRegion: "coalesce(region_code, 'unknown')",
+}
+
+const statsQuery = `
+	SELECT %s AS key_s, count(*) AS count_s, (sum(count(*)) OVER ())::bigint AS total_s
+	%s
+	GROUP BY key_s
+	ORDER BY count_s DESC, key_s
+	LIMIT @limit`
+
+// Stats counts the cars matching the filter grouped by q.GroupBy and returns
+// the q.Top largest groups. The total is computed before the limit applies.
+func (r *PgCarRepository) Stats(ctx context.Context, filter mod.CarFilter, q mod.StatsQuery) (mod.Stats, error) {
+	key, ok := statsKeys[q.GroupBy]
+	if !ok {
+		return mod.Stats{}, internal.ErrUnknownGroup
+	}
+	from, args := filteredCars(filter)
+	args["bucket"] = max(q.YearBucket, 1)
+	args["limit"] = q.Top
+
+	rows, err := r.pool.Query(ctx, fmt.Sprintf(statsQuery, key, from), args)
+	if err != nil {
+		log.Error().Err(err).Str("group by", q.GroupBy).Msg("can't get stats")
+		return mod.Stats{}, err
+	}
+	defer rows.Close()
+
+	stats := mod.Stats{GroupBy: q.GroupBy}
+	for rows.Next() {
+		g := mod.StatGroup{}
+		if err = rows.Scan(&g.Key, &g.Count, &stats.Total); err != nil {
+			log.Error().Err(err).Msg("can't read stats")
+			return mod.Stats{}, err
+		}
+		stats.Groups = append(stats.Groups, g)
+	}
+	if err = rows.Err(); err != nil {
+		log.Error().Err(err).Str("group by", q.GroupBy).Msg("can't read stats")
+		return mod.Stats{}, err
+	}
+	return stats, nil
+}
@@ -0,0 +1,109 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype/zeronull"
+	"github.com/mi-raf/cars-catalog/internal"
+	mod "github.com/mi-raf/cars-catalog/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	auditActionStatus = "status"
+
+	// the car must still be in the status the transition was checked from
+	changeStatus = `UPDATE Car SET status = $3, status_reason = $4, version = version + 1
+	WHERE reg_num = $1 AND deleted_at IS NULL AND status = $2 AND ($5::integer IS NULL OR version = $5::integer)`
+
+	selectCarStatus = "SELECT status FROM Car WHERE reg_num = $1 AND deleted_at IS NULL"
+	lockCarStatus   = selectCarStatus + " FOR UPDATE"
+
+	writeUpdate   = "update"
+	writeTransfer = "transfer"
+	writeReplate  = "replate"
+	writeMileage  = "mileage"
+)
+
+// refusedWrites lists the writes a car in the status does not accept.
+// Scrapped and exported cars are frozen apart from maintenance records; a
+// stolen car keeps its owner and plates until it is recovered. Active and sold
+// cars accept every write.
+var refusedWrites = map[string][]string{
+	mod.StatusScrapped: {writeUpdate, writeTransfer, writeReplate, writeMileage},
+	mod.StatusExported: {writeUpdate, writeTransfer, writeReplate, writeMileage},
+	mod.StatusStolen:   {writeTransfer, writeReplate},
+}
+
+// ChangeStatus moves the car from req.From to req.To. The transition itself is
+// checked by the service; a car which is no longer in req.From is reported as
+// internal.ErrBadTransition, one whose version moved on as
+// internal.ErrVersionMismatch.
+func (r *PgCarRepository) ChangeStatus(ctx context.Context, req mod.StatusChange) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("can't open transaction for status")
+		return err
+	}
+
+	defer func() {
+		err = tx.Rollback(ctx)
+		if !errors.Is(err, pgx.ErrTxClosed) {
+			log.Error().Err(err).Msg("Undefinded error in tx")
+		}
+	}()
+
+	affected, err := mutateCar(ctx, tx, req.RegNum, auditActionStatus, changeStatus, req.RegNum, req.From, req.To, req.Reason, zeronull.Int4(req.Version))
+	if err != nil {
+		log.Error().Err(err).Str("reg num", req.RegNum).Msg("can't change status")
+		return err
+	}
+	if affected == 0 {
+		log.Debug().Str("reg num", req.RegNum).Str("from", req.From).Int32("version", req.Version).Msg("status not changed")
+		return statusConflict(tx.QueryRow(ctx, selectCarStatus, req.RegNum), req)
+	}
+	log.Debug().Str("reg num", req.RegNum).Str("from", req.From).Str("to", req.To).Msg("change status")
+	return tx.Commit(ctx)
+}
+
+// checkWritable locks the car for the rest of the transaction and refuses the
+// write with internal.ErrStatusForbids when its status does not allow it, so a
+// concurrent status change can not slip in between. A missing car is left to
+// the write to report.
+func checkWritable(ctx context.Context, tx pgx.Tx, regNum, write string) error {
+	var status string
+	err := tx.QueryRow(ctx, lockCarStatus, regNum).Scan(&status)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		log.Error().Err(err).Str("reg num", regNum).Msg("can't lock car status")
+		return err
+	}
+	for _, w := range refusedWrites[status] {
+		if w == write {
+			log.Debug().Str("reg num", regNum).Str("status", status).Str("write", write).Msg("write refused by status")
+			return fmt.Errorf("%w: %s of a %s car", internal.ErrStatusForbids, write, status)
+		}
+	}
+	return nil
+}
+
+// statusConflict explains why a status change affected no rows.
+func statusConflict(row pgx.Row, req mod.StatusChange) error {
+	var current string
+	err := row.Scan(&current)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return internal.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if current != req.From {
+		return fmt.Errorf("%w: the car became %s before it could go from %s to %s", internal.ErrBadTransition, current, req.From, req.To)
+	}
+	return internal.ErrVersionMismatch
+}
@@ -0,0 +1,77 @@
+package database
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype/zeronull"
+	mod "github.com/mi-raf/cars-catalog/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+// Suggestions are prefix matches among active cars ranked by how many cars
+// share the value. Conditions mirror the indexes of migration 000007.
+const (
+	suggestMarks = `
+	SELECT mark, count(*) AS count_s FROM Car
+	WHERE deleted_at IS NULL AND lower(mark) LIKE lower($1)
+	GROUP BY mark
+	ORDER BY count_s DESC, mark
+	LIMIT $2`
+
+	suggestModels = `
+	SELECT model, count(*) AS count_s FROM Car
+	WHERE deleted_at IS NULL AND ($1::varchar IS NULL OR lower(mark) = lower($1::varchar)) AND lower(model) LIKE lower($2)
+	GROUP BY model
+	ORDER BY count_s DESC, model
+	LIMIT $3`
+
+	suggestOwners = `
+	SELECT concat_ws(' ', p.surname_p, p.name_p, NULLIF(p.patronymic_p, '')) AS owner, count(Car.reg_num) AS count_s
+	FROM People AS p LEFT JOIN Car
+	ON Car.id_p = p.id_p AND Car.deleted_at IS NULL
+	WHERE lower(p.surname_p || ' ' || p.name_p) LIKE lower($1) OR lower(p.name_p || ' ' || p.surname_p) LIKE lower($1)
+	GROUP BY p.id_p
+	ORDER BY count_s DESC, owner
+	LIMIT $2`
+)
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// prefixPattern turns user input into a LIKE pattern matching it literally as a prefix.
+func prefixPattern(prefix string) string {
+	return likeEscaper.Replace(prefix) + "%"
+}
+
+func (r *PgCarRepository) SuggestMarks(ctx context.Context, prefix string, limit int) ([]mod.StatGroup, error) {
+	return r.suggest(ctx, "mark", suggestMarks, prefixPattern(prefix), limit)
+}
+
+// SuggestModels suggests models of the given mark, or of any mark when it is empty.
+func (r *PgCarRepository) SuggestModels(ctx context.Context, mark, prefix string, limit int) ([]mod.StatGroup, error) {
+	return r.suggest(ctx, "model", suggestModels, zeronull.Text(mark), prefixPattern(prefix), limit)
+}
+
+// SuggestOwners matches the prefix against "surname name" and "name surname".
+func (r *PgCarRepository) SuggestOwners(ctx context.Context, prefix string, limit int) ([]mod.StatGroup, error) {
+	return r.suggest(ctx, "owner", suggestOwners, prefixPattern(prefix), limit)
+}
+
+func (r *PgCarRepository) suggest(ctx context.Context, what, q string, args ...any) ([]mod.StatGroup, error) {
+	rows, err := r.pool.Query(ctx, q, args...)
+	if err != nil {
+		log.Error().Err(err).Str("suggest", what).Msg("can't get suggestions")
+		return nil, err
+	}
+	res, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (mod.StatGroup, error) {
+		g := mod.StatGroup{}
+		err := row.Scan(&g.Key, &g.Count)
+		return g, err
+	})
+	if err != nil {
+		log.Error().Err(err).Str("suggest", what).Msg("can't read suggestions")
+		return nil, err
+	}
+	return res, nil
+}
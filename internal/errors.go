@@ -1,6 +1,28 @@
 package internal
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrNotFound        = errors.New("not found")
+	ErrVersionMismatch = errors.New("version mismatch")
+	ErrEmptyFilter     = errors.New("empty filter")
+	ErrTooManyMatches  = errors.New("too many matches")
+	ErrNotDeleted      = errors.New("not deleted")
+	ErrSameOwner       = errors.New("same owner")
+	ErrUnknownGroup    = errors.New("unknown group")
+	ErrEmptyQuery      = errors.New("empty query")
+	ErrDuplicateVIN    = errors.New("duplicate vin")
+	ErrSamePlate       = errors.New("same plate")
+	ErrPlateTaken      = errors.New("plate taken")
+	ErrUnknownSort     = errors.New("unknown sort")
+	ErrMileageRollback = errors.New("mileage rollback")
+	ErrNoMaintenance   = errors.New("no maintenance")
+	ErrBadTransition   = errors.New("bad status transition")
+	ErrStatusForbids   = errors.New("not allowed by status")
+)
 
 type ClientError struct {
 	Code int
@@ -1,19 +1,84 @@
 package internal
 
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	DeleteStatusDeleted  = "deleted"
+	DeleteStatusNotFound = "not_found"
+
+	StatsByMark   = "mark"
+	StatsByModel  = "model"
+	StatsByYear   = "year"
+	StatsByOwner  = "owner"
+	StatsByRegion = "region"
+
+	FacetMark  = "mark"
+	FacetModel = "model"
+	FacetYear  = "year"
+
+	SortRegNum       = "reg_num"
+	SortMark         = "mark"
+	SortModel        = "model"
+	SortYear         = "year"
+	SortColor        = "color"
+	SortBodyType     = "body_type"
+	SortFuel         = "fuel"
+	SortEngineVolume = "engine_volume"
+	SortTransmission = "transmission"
+	SortMileage      = "mileage"
+
+	StatusActive   = "active"
+	StatusSold     = "sold"
+	StatusStolen   = "stolen"
+	StatusScrapped = "scrapped"
+	StatusExported = "exported"
+)
+
 type (
+	// the max lengths of PeopleDTO and CarDTO match their columns so that
+	// over-long values are rejected before they reach the database
 	PeopleDTO struct {
 		Id         int64
-		Name       string `validate:"required"`
-		Surname    string `validate:"required"`
-		Patronymic string
+		Name       string `validate:"required,max=20"`
+		Surname    string `validate:"required,max=60"`
+		Patronymic string `validate:"max=40"`
 	}
 
 	CarDTO struct {
-		RegNum string `validate:"required"`
-		Mark   string `validate:"required"`
-		Model  string `validate:"required"`
-		Year   int32  `validate:"c-year"`
-		Owner  *PeopleDTO
+		// ID stays the same when the car gets new plates
+		ID        int64
+		RegNum    string `validate:"required,plate"`
+		Mark      string `validate:"required,max=40"`
+		Model     string `validate:"required,max=40"`
+		Year      int32  `validate:"c-year"`
+		Version   int32
+		DeletedAt time.Time
+		Owner     *PeopleDTO
+		// PlateType and RegionCode are detected from RegNum when the car is added
+		PlateType  string
+		RegionCode string
+		VIN        string `validate:"omitempty,vin"`
+
+		Color    string `validate:"omitempty,max=30"`
+		BodyType string `validate:"omitempty,oneof=sedan hatchback wagon coupe convertible suv crossover minivan pickup van bus truck motorcycle"`
+		Fuel     string `validate:"omitempty,oneof=petrol diesel lpg cng hybrid electric"`
+		// EngineVolume is in cubic centimetres
+		EngineVolume int32  `validate:"omitempty,min=50,max=20000"`
+		Transmission string `validate:"omitempty,oneof=manual automatic robot cvt"`
+		// Mileage is in kilometres
+		Mileage int32 `validate:"gte=0"`
+		// SuspiciousRollback is set once an odometer reading lower than an
+		// earlier one has been logged
+		SuspiciousRollback bool
+		// LastServiceOn is the date of the latest maintenance, it is only
+		// filled when CarFilter.LastService is set
+		LastServiceOn time.Time
+		// Status changes only through the transitions allowed by the service
+		Status       string
+		StatusReason string
 	}
 
 	CarFilter struct {
@@ -24,5 +89,179 @@ type (
 		Name       string
 		Surname    string
 		Patronymic string
+		// Region is the region code of the plate
+		Region string
+		VIN    string
+
+		Color        string
+		BodyType     string
+		Fuel         string
+		Transmission string
+		// zero bounds of the ranges are not applied
+		MileageMin      int32
+		MileageMax      int32
+		EngineVolumeMin int32
+		EngineVolumeMax int32
+		Status          string
+
+		// Sort is a comma separated list of sort keys, "-" before a key sorts
+		// descending; it only orders listings and never narrows them
+		Sort string
+
+		IncludeDeleted bool
+		// AsOf selects the state of the catalog at the given instant
+		AsOf time.Time
+		// LastService expands the cars with the date of their latest maintenance
+		LastService bool
+	}
+
+	BatchDelete struct {
+		RegNums []string
+		Filter  *CarFilter
+		DryRun  bool
+		// MaxMatches is the most cars Filter may match, zero is no limit
+		MaxMatches int
+	}
+
+	DeleteResult struct {
+		RegNum string
+		Status string
+	}
+
+	AuditRecord struct {
+		ID        int64
+		Entity    string
+		EntityID  string
+		Action    string
+		Actor     string
+		RequestID string
+		Before    json.RawMessage
+		After     json.RawMessage
+		CreatedAt time.Time
+	}
+
+	StatusChange struct {
+		RegNum string
+		From   string
+		To     string `validate:"required,oneof=active sold stolen scrapped exported"`
+		Reason string `validate:"required,max=500"`
+		// Version makes the change conditional when it is not zero
+		Version int32
+	}
+
+	// MileageReading is an odometer reading of a car. Rollback marks a reading
+	// which was logged out of order because the client overrode the check.
+	MileageReading struct {
+		Mileage   int32     `validate:"gte=0"`
+		ReadOn    time.Time `validate:"required"`
+		Rollback  bool
+		CreatedAt time.Time
+	}
+
+	// Maintenance is a service event of a car. Cost is in kopecks.
+	Maintenance struct {
+		ID        int64
+		DoneOn    time.Time `validate:"required"`
+		Mileage   int32     `validate:"gte=0"`
+		WorkType  string    `validate:"required,oneof=service oil_change inspection repair tires brakes battery bodywork other"`
+		Cost      int64     `validate:"gte=0"`
+		Notes     string    `validate:"max=2000"`
+		CreatedAt time.Time
+		UpdatedAt time.Time
+	}
+
+	// MaintenanceFilter narrows the maintenance of a car; zero values are not
+	// applied and both dates are inclusive.
+	MaintenanceFilter struct {
+		From     time.Time
+		To       time.Time
+		WorkType string
+	}
+
+	Ownership struct {
+		Owner     PeopleDTO
+		ValidFrom time.Time
+		ValidTo   time.Time
+	}
+
+	// ImportRow is a car read from an uploaded file with its line number.
+	ImportRow struct {
+		Line int
+		Car  CarDTO
+	}
+
+	ImportError struct {
+		Line   int
+		RegNum string
+		Msg    string
+	}
+
+	Replate struct {
+		RegNum    string
+		NewRegNum string
+		// PlateType and RegionCode are detected from NewRegNum
+		PlateType  string
+		RegionCode string
+		Version    int32
+	}
+
+	PlateChange struct {
+		OldRegNum string
+		NewRegNum string
+		ChangedAt time.Time
+	}
+
+	// VINMismatch is a car whose mark differs from the manufacturer of its VIN.
+	VINMismatch struct {
+		RegNum      string
+		VIN         string
+		Mark        string
+		DecodedMark string
+	}
+
+	ImportReport struct {
+		DryRun   bool
+		Total    int
+		Valid    int
+		Imported []string
+		// Skipped lists cars which already exist in the catalog
+		Skipped []string
+		Errors  []ImportError
+		// Failed is the batch which could not be saved; the batches before it
+		// are committed and the rows after it were not attempted
+		Failed *ImportFailure
+	}
+
+	ImportFailure struct {
+		FromLine int
+		ToLine   int
+		Msg      string
+	}
+
+	StatsQuery struct {
+		GroupBy string
+		// YearBucket is the width in years of a group when grouping by year
+		YearBucket int32
+		// Top limits the number of the largest groups returned
+		Top int
+	}
+
+	StatGroup struct {
+		Key   string
+		Count int64
+	}
+
+	Stats struct {
+		GroupBy string
+		// Total counts all matching cars, including those outside the top groups
+		Total  int64
+		Groups []StatGroup
+	}
+
+	SearchHit struct {
+		Car  CarDTO
+		Rank float64
+		// Highlight is the car and owner text with matched words wrapped in <mark> tags
+		Highlight string
 	}
 )
@@ -0,0 +1,119 @@
+package plate
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sync"
+)
+
+const (
+	TypeCivil      = "civil"
+	TypeTaxi       = "taxi"
+	TypeTrailer    = "trailer"
+	TypeMoto       = "moto"
+	TypePolice     = "police"
+	TypeDiplomatic = "diplomatic"
+
+	CountryRU = "RU"
+)
+
+// russianLetter is the set of letters allowed on Russian plates after normalization.
+const russianLetter = `[ABEKMHOPCTYX]`
+
+type (
+	// Format describes one kind of plate. The pattern is matched against the
+	// normalized plate; a named group "region" captures the region code.
+	Format struct {
+		Type    string `json:"type"`
+		Country string `json:"country"`
+		Pattern string `json:"pattern"`
+
+		re *regexp.Regexp
+	}
+
+	Match struct {
+		Type    string
+		Country string
+		Region  string
+	}
+
+	// Registry detects plate formats. Formats are tried in the order they
+	// were registered and the first match wins.
+	Registry struct {
+		mu      sync.RWMutex
+		formats []Format
+	}
+)
+
+// RussianFormats returns the GOST R 50577 plate formats.
+func RussianFormats() []Format {
+	region := `(?P<region>\d{2,3})`
+	return []Format{
+		{Type: TypeCivil, Country: CountryRU, Pattern: `^` + russianLetter + `\d{3}` + russianLetter + `{2}` + region + `$`},
+		{Type: TypeTaxi, Country: CountryRU, Pattern: `^` + russianLetter + `{2}\d{3}` + region + `$`},
+		{Type: TypeTrailer, Country: CountryRU, Pattern: `^` + russianLetter + `{2}\d{4}` + region + `$`},
+		{Type: TypeMoto, Country: CountryRU, Pattern: `^\d{4}` + russianLetter + `{2}` + region + `$`},
+		{Type: TypePolice, Country: CountryRU, Pattern: `^` + russianLetter + `\d{4}` + region + `$`},
+		{Type: TypeDiplomatic, Country: CountryRU, Pattern: `^\d{3}(?:CD\d|[DT]\d{3})` + region + `$`},
+	}
+}
+
+// NewRegistry returns a registry with the given formats.
+func NewRegistry(formats ...Format) (*Registry, error) {
+	r := &Registry{}
+	for _, f := range formats {
+		if err := r.Register(f); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// Register adds a format after the already known ones.
+func (r *Registry) Register(f Format) error {
+	if len(f.Type) < 1 {
+		return fmt.Errorf("plate format without type: %q", f.Pattern)
+	}
+	re, err := regexp.Compile(f.Pattern)
+	if err != nil {
+		return fmt.Errorf("plate format %s: %w", f.Type, err)
+	}
+	f.re = re
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.formats = append(r.formats, f)
+	return nil
+}
+
+// LoadFormats reads a JSON array of formats from a file.
+func LoadFormats(path string) ([]Format, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var formats []Format
+	if err = json.Unmarshal(data, &formats); err != nil {
+		return nil, fmt.Errorf("plate formats %s: %w", path, err)
+	}
+	return formats, nil
+}
+
+// Detect finds the format of a normalized plate.
+func (r *Registry) Detect(p string) (Match, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, f := range r.formats {
+		m := f.re.FindStringSubmatch(p)
+		if m == nil {
+			continue
+		}
+		res := Match{Type: f.Type, Country: f.Country}
+		if i := f.re.SubexpIndex("region"); i > 0 {
+			res.Region = m[i]
+		}
+		return res, true
+	}
+	return Match{}, false
+}
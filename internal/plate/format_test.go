@@ -0,0 +1,54 @@
+package plate_test
+
+import (
+	"testing"
+
+	"github.com/mi-raf/cars-catalog/internal/plate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectRussianFormats(t *testing.T) {
+	r, err := plate.NewRegistry(plate.RussianFormats()...)
+	require.NoError(t, err)
+
+	cases := []struct {
+		plate, typ, region string
+	}{
+		{"X123XX150", plate.TypeCivil, "150"},
+		{"A001AA77", plate.TypeCivil, "77"},
+		{"AB12323", plate.TypeTaxi, "23"},
+		{"AB1234777", plate.TypeTrailer, "777"},
+		{"1234AB50", plate.TypeMoto, "50"},
+		{"A123477", plate.TypePolice, "77"},
+		{"001CD177", plate.TypeDiplomatic, "77"},
+		{"123D123199", plate.TypeDiplomatic, "199"},
+	}
+	for _, c := range cases {
+		m, ok := r.Detect(c.plate)
+		if assert.True(t, ok, c.plate) {
+			assert.Equal(t, c.typ, m.Type, c.plate)
+			assert.Equal(t, c.region, m.Region, c.plate)
+			assert.Equal(t, plate.CountryRU, m.Country, c.plate)
+		}
+	}
+
+	for _, p := range []string{"", "Д123ЖЖ77", "X123XX1500", "hello", "X123XX1"} {
+		_, ok := r.Detect(p)
+		assert.False(t, ok, p)
+	}
+}
+
+func TestRegisterCustomFormat(t *testing.T) {
+	r, err := plate.NewRegistry(plate.RussianFormats()...)
+	require.NoError(t, err)
+	require.NoError(t, r.Register(plate.Format{Type: "civil", Country: "BY", Pattern: `^\d{4}[A-Z]{2}(?P<region>\d)$`}))
+
+	m, ok := r.Detect("1234AB7")
+	assert.True(t, ok)
+	assert.Equal(t, "BY", m.Country)
+	assert.Equal(t, "7", m.Region)
+
+	assert.Error(t, r.Register(plate.Format{Type: "broken", Pattern: `(`}))
+	assert.Error(t, r.Register(plate.Format{Pattern: `^A$`}))
+}
@@ -0,0 +1,34 @@
+// Package plate canonicalizes vehicle registration numbers.
+package plate
+
+import (
+	"strings"
+	"unicode"
+)
+
+// homoglyphs maps the Cyrillic letters allowed on Russian plates to the Latin
+// letters they look like. Plates are stored in the Latin alphabet.
+var homoglyphs = map[rune]rune{
+	'А': 'A', 'В': 'B', 'Е': 'E', 'К': 'K', 'М': 'M', 'Н': 'H',
+	'О': 'O', 'Р': 'P', 'С': 'C', 'Т': 'T', 'У': 'Y', 'Х': 'X',
+}
+
+// Normalize returns the canonical form of a plate: whitespace removed, upper
+// case and Cyrillic homoglyphs replaced by Latin letters, so that "х 123 хх150"
+// and "X123XX150" are the same plate. Other characters are kept as they are.
+// It must stay in line with the normalize_plate database function.
+func Normalize(p string) string {
+	var b strings.Builder
+	b.Grow(len(p))
+	for _, r := range p {
+		if unicode.IsSpace(r) {
+			continue
+		}
+		r = unicode.ToUpper(r)
+		if l, ok := homoglyphs[r]; ok {
+			r = l
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
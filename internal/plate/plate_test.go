@@ -0,0 +1,24 @@
+package plate_test
+
+import (
+	"testing"
+
+	"github.com/mi-raf/cars-catalog/internal/plate"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeMergesAlphabets(t *testing.T) {
+	assert.Equal(t, "X123XX150", plate.Normalize("X123XX150"))
+	assert.Equal(t, "X123XX150", plate.Normalize("Х123ХХ150"))
+	assert.Equal(t, "X123XX150", plate.Normalize("х123хХ150"))
+	assert.Equal(t, "ABEKMHOPCTYX", plate.Normalize("авекмнорстух"))
+}
+
+func TestNormalizeStripsWhitespace(t *testing.T) {
+	assert.Equal(t, "A001AA77", plate.Normalize(" a 001\tаа 77\n"))
+	assert.Equal(t, "", plate.Normalize("  "))
+}
+
+func TestNormalizeKeepsOtherLetters(t *testing.T) {
+	assert.Equal(t, "Д123Ж77", plate.Normalize("д123ж77"))
+}
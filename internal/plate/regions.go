@@ -0,0 +1,54 @@
+package plate
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+)
+
+// regionsJSON is the table of Russian region codes. Bump its version whenever
+// codes are added or reassigned.
+//
+//go:embed regions.json
+var regionsJSON []byte
+
+var regions = mustLoadRegions(regionsJSON)
+
+type regionTable struct {
+	Version string `json:"version"`
+	Regions []struct {
+		Name  string   `json:"name"`
+		Codes []string `json:"codes"`
+	} `json:"regions"`
+
+	names map[string]string
+}
+
+func mustLoadRegions(data []byte) *regionTable {
+	t := &regionTable{}
+	if err := json.Unmarshal(data, t); err != nil {
+		panic(fmt.Sprintf("plate: can't read region table: %v", err))
+	}
+	t.names = make(map[string]string)
+	for _, r := range t.Regions {
+		for _, c := range r.Codes {
+			if prev, ok := t.names[c]; ok {
+				panic(fmt.Sprintf("plate: region code %s belongs to %s and %s", c, prev, r.Name))
+			}
+			t.names[c] = r.Name
+		}
+	}
+	return t
+}
+
+// RegionName returns the name of the Russian region with the given plate code
+// or an empty string when the code is unknown. Several codes may name the same
+// region, e.g. 50 and 150 are both Moscow Oblast.
+func RegionName(code string) string {
+	return regions.names[code]
+}
+
+// RegionsVersion is the version of the embedded region table.
+func RegionsVersion() string {
+	return regions.Version
+}
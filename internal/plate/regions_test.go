@@ -0,0 +1,19 @@
+package plate_test
+
+import (
+	"testing"
+
+	"github.com/mi-raf/cars-catalog/internal/plate"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegionName(t *testing.T) {
+	assert.Equal(t, "Moscow Oblast", plate.RegionName("150"))
+	assert.Equal(t, "Moscow Oblast", plate.RegionName("50"))
+	assert.Equal(t, "Krasnodar Krai", plate.RegionName("23"))
+	assert.Equal(t, "Republic of Adygea", plate.RegionName("01"))
+	assert.Empty(t, plate.RegionName("1"))
+	assert.Empty(t, plate.RegionName("00"))
+	assert.Empty(t, plate.RegionName(""))
+	assert.NotEmpty(t, plate.RegionsVersion())
+}
@@ -0,0 +1,29 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	mod "github.com/mi-raf/cars-catalog/internal/models"
+	"github.com/mi-raf/cars-catalog/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListReadDuringWriteIsNotCached(t *testing.T) {
+	ctx := context.Background()
+	r := &fakeRepo{current: "A001AA77"}
+	s := newService(t, r, service.Options{Cache: service.NewCarListCache(8, time.Minute)})
+	r.duringRead = func() { require.NoError(t, s.Delete(ctx, "A001AA77", 0)) }
+
+	_, err := s.GetAll(ctx, mod.CarFilter{}, 0, 10)
+	require.NoError(t, err)
+	_, err = s.GetAll(ctx, mod.CarFilter{}, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 2, r.reads, "the result read before the delete was cached")
+
+	_, err = s.GetAll(ctx, mod.CarFilter{}, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 2, r.reads, "a result read after the delete was not cached")
+}
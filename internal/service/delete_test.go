@@ -0,0 +1,37 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mi-raf/cars-catalog/internal"
+	mod "github.com/mi-raf/cars-catalog/internal/models"
+	"github.com/mi-raf/cars-catalog/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteBatchRefusesEmptyFilter(t *testing.T) {
+	r := &fakeRepo{}
+	s := newService(t, r, service.Options{})
+
+	_, err := s.DeleteBatch(context.Background(), mod.BatchDelete{Filter: &mod.CarFilter{Mark: " ", IncludeDeleted: true}})
+
+	assert.ErrorIs(t, err, internal.ErrEmptyFilter)
+	assert.Nil(t, r.batch)
+}
+
+func TestDeleteBatchCapsFilterMatches(t *testing.T) {
+	ctx := context.Background()
+	r := &fakeRepo{}
+	s := newService(t, r, service.Options{BatchDelete: &service.BatchDeleteConfig{MaxMatches: 50}})
+
+	_, err := s.DeleteBatch(ctx, mod.BatchDelete{Filter: &mod.CarFilter{Mark: "Lada"}})
+	require.NoError(t, err)
+	assert.Equal(t, 50, r.batch.MaxMatches)
+
+	s = newService(t, r, service.Options{})
+	_, err = s.DeleteBatch(ctx, mod.BatchDelete{Filter: &mod.CarFilter{Mark: "Lada"}})
+	require.NoError(t, err)
+	assert.Equal(t, service.DEFAULT_MAX_DELETE_MATCHES, r.batch.MaxMatches)
+}
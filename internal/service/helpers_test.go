@@ -0,0 +1,138 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/mi-raf/cars-catalog/internal"
+	"github.com/mi-raf/cars-catalog/internal/database"
+	mod "github.com/mi-raf/cars-catalog/internal/models"
+	"github.com/mi-raf/cars-catalog/internal/plate"
+	"github.com/mi-raf/cars-catalog/internal/service"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRepo knows one active car with the current plate, which was re-plated
+// from old when old is set, and records what the service asks of it. Calls the
+// tests do not expect panic on the embedded nil repository.
+type fakeRepo struct {
+	database.CarRepository
+	old, current string
+	status       string
+	readings     []mod.MileageReading
+
+	// reads counts list reads; duringRead runs once during a read, standing
+	// in for a write which commits while the list is read.
+	reads      int
+	duringRead func()
+
+	// batch is the last batch delete asked for.
+	batch *mod.BatchDelete
+
+	// batches counts imported batches; the failOn-th one fails.
+	batches int
+	failOn  int
+}
+
+func (r *fakeRepo) CurrentPlate(_ context.Context, regNum string) (string, error) {
+	if r.old == "" || regNum != r.old {
+		return "", internal.ErrNotFound
+	}
+	return r.current, nil
+}
+
+func (r *fakeRepo) Get(_ context.Context, regNum string) (*mod.CarDTO, error) {
+	if regNum != r.current {
+		return nil, internal.ErrNotFound
+	}
+	status := r.status
+	if status == "" {
+		status = mod.StatusActive
+	}
+	return &mod.CarDTO{RegNum: regNum, Mark: "Lada", Model: "Vesta", Status: status, Owner: &mod.PeopleDTO{}}, nil
+}
+
+func (r *fakeRepo) GetAll(_ context.Context, _ mod.CarFilter, _, _ int) ([]mod.CarDTO, error) {
+	r.reads++
+	if r.duringRead != nil {
+		write := r.duringRead
+		r.duringRead = nil
+		write()
+	}
+	return []mod.CarDTO{{RegNum: r.current}}, nil
+}
+
+func (r *fakeRepo) Delete(_ context.Context, regNum string, _ int32) error {
+	if regNum != r.current {
+		return internal.ErrNotFound
+	}
+	return nil
+}
+
+func (r *fakeRepo) DeleteBatch(_ context.Context, req mod.BatchDelete) ([]mod.DeleteResult, error) {
+	r.batch = &req
+	return nil, nil
+}
+
+func (r *fakeRepo) Transfer(_ context.Context, regNum string, _ *mod.PeopleDTO, _ int32) error {
+	if regNum != r.current {
+		return internal.ErrNotFound
+	}
+	return nil
+}
+
+func (r *fakeRepo) Owners(_ context.Context, regNum string) ([]mod.Ownership, error) {
+	if regNum != r.current {
+		return nil, internal.ErrNotFound
+	}
+	return []mod.Ownership{{Owner: mod.PeopleDTO{Name: "Ivan", Surname: "Scott"}}}, nil
+}
+
+func (r *fakeRepo) AddMileage(_ context.Context, regNum string, reading mod.MileageReading, _ bool) error {
+	if regNum != r.current {
+		return internal.ErrNotFound
+	}
+	r.readings = append(r.readings, reading)
+	return nil
+}
+
+func (r *fakeRepo) Mileage(_ context.Context, regNum string) ([]mod.MileageReading, error) {
+	if regNum != r.current {
+		return nil, internal.ErrNotFound
+	}
+	return r.readings, nil
+}
+
+func (r *fakeRepo) Plates(_ context.Context, regNum string) ([]mod.PlateChange, error) {
+	if r.old == "" || regNum != r.current {
+		return nil, nil
+	}
+	return []mod.PlateChange{{OldRegNum: r.old, NewRegNum: r.current}}, nil
+}
+
+func (r *fakeRepo) Import(_ context.Context, cars []mod.CarDTO, _ bool) ([]string, error) {
+	r.batches++
+	if r.batches == r.failOn {
+		return nil, errors.New("connection reset")
+	}
+	res := make([]string, 0, len(cars))
+	for _, c := range cars {
+		res = append(res, c.RegNum)
+	}
+	return res, nil
+}
+
+// newService builds the service the way main does, with the Russian plate
+// formats registered.
+func newService(t *testing.T, r database.CarRepository, opts service.Options) *service.CarServise {
+	plates, err := plate.NewRegistry(plate.RussianFormats()...)
+	require.NoError(t, err)
+	v := validator.New(validator.WithRequiredStructEnabled())
+	require.NoError(t, v.RegisterValidation("c-year", internal.LessThanCurrYearValidator))
+	require.NoError(t, v.RegisterValidation("plate", internal.PlateValidator(plates)))
+	require.NoError(t, v.RegisterValidation("vin", internal.VINValidator))
+	opts.Plates = plates
+	return service.NewCarService(r, nil, v, opts)
+}
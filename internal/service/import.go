@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	mod "github.com/mi-raf/cars-catalog/internal/models"
+	"github.com/mi-raf/cars-catalog/internal/plate"
+	"github.com/mi-raf/cars-catalog/internal/vin"
+	"github.com/rs/zerolog/log"
+)
+
+const DEFAULT_IMPORT_BATCH_SIZE = 1000
+
+type ImportConfig struct {
+	// BatchSize is the number of cars committed in one transaction.
+	BatchSize int
+}
+
+// Import validates the uploaded rows and adds the valid ones in batches, each
+// batch in its own transaction. Invalid rows are reported with their line
+// numbers and do not prevent the rest from being imported. On error the
+// report covers the batches committed so far.
+func (c *CarServise) Import(ctx context.Context, rows []mod.ImportRow, dryRun bool) (mod.ImportReport, error) {
+	report := mod.ImportReport{DryRun: dryRun, Total: len(rows)}
+
+	seen := make(map[string]int, len(rows))
+	seenVIN := make(map[string]int)
+	valid := make([]mod.CarDTO, 0, len(rows))
+	lines := make([]int, 0, len(rows))
+	for i := range rows {
+		row := &rows[i]
+		normalizeCar(&row.Car)
+		if msg := c.validateImport(&row.Car); len(msg) > 0 {
+			report.Errors = append(report.Errors, mod.ImportError{Line: row.Line, RegNum: row.Car.RegNum, Msg: msg})
+			continue
+		}
+		if first, ok := seen[row.Car.RegNum]; ok {
+			msg := fmt.Sprintf("duplicate registration number, first seen on line %d", first)
+			report.Errors = append(report.Errors, mod.ImportError{Line: row.Line, RegNum: row.Car.RegNum, Msg: msg})
+			continue
+		}
+		if first, ok := seenVIN[row.Car.VIN]; ok {
+			msg := fmt.Sprintf("duplicate VIN, first seen on line %d", first)
+			report.Errors = append(report.Errors, mod.ImportError{Line: row.Line, RegNum: row.Car.RegNum, Msg: msg})
+			continue
+		}
+		seen[row.Car.RegNum] = row.Line
+		if len(row.Car.VIN) > 0 {
+			seenVIN[row.Car.VIN] = row.Line
+		}
+		c.detectPlate(&row.Car)
+		valid = append(valid, row.Car)
+		lines = append(lines, row.Line)
+	}
+	report.Valid = len(valid)
+
+	if !dryRun {
+		defer c.invalidate()
+	}
+	batch := c.importBatchSize()
+	for start := 0; start < len(valid); start += batch {
+		end := min(start+batch, len(valid))
+		cars := valid[start:end]
+		imported, err := c.r.Import(ctx, cars, dryRun)
+		if err != nil {
+			log.Error().Err(err).Int("offset", start).Msg("can't import batch of cars")
+			report.Failed = &mod.ImportFailure{FromLine: lines[start], ToLine: lines[end-1], Msg: "batch could not be saved, the rows from this one on were not imported"}
+			return report, err
+		}
+		report.Imported = append(report.Imported, imported...)
+		report.Skipped = append(report.Skipped, skippedCars(cars, imported)...)
+	}
+	log.Debug().Int("total", report.Total).Int("valid", report.Valid).Int("imported", len(report.Imported)).Bool("dry run", dryRun).Msg("import cars")
+	return report, nil
+}
+
+func (c *CarServise) importBatchSize() int {
+	if c.importCfg == nil || c.importCfg.BatchSize < 1 {
+		return DEFAULT_IMPORT_BATCH_SIZE
+	}
+	return c.importCfg.BatchSize
+}
+
+// validateImport returns a readable description of what is wrong with the car
+// or an empty string.
+func (c *CarServise) validateImport(car *mod.CarDTO) string {
+	if car.Owner == nil {
+		return "owner is required"
+	}
+	err := c.v.Struct(car)
+	if err == nil {
+		return ""
+	}
+	var verr validator.ValidationErrors
+	if !errors.As(err, &verr) {
+		return err.Error()
+	}
+	msgs := make([]string, 0, len(verr))
+	for _, fe := range verr {
+		msgs = append(msgs, fmt.Sprintf("%s failed on %s", fe.Namespace(), fe.Tag()))
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func normalizeCar(car *mod.CarDTO) {
+	car.RegNum = plate.Normalize(car.RegNum)
+	car.VIN = vin.Normalize(car.VIN)
+	car.Mark = strings.TrimSpace(car.Mark)
+	car.Model = strings.TrimSpace(car.Model)
+	car.Color = strings.TrimSpace(car.Color)
+	if car.Owner != nil {
+		car.Owner.Name = strings.TrimSpace(car.Owner.Name)
+		car.Owner.Surname = strings.TrimSpace(car.Owner.Surname)
+		car.Owner.Patronymic = strings.TrimSpace(car.Owner.Patronymic)
+	}
+}
+
+func skippedCars(cars []mod.CarDTO, imported []string) []string {
+	done := make(map[string]struct{}, len(imported))
+	for _, r := range imported {
+		done[r] = struct{}{}
+	}
+	var skipped []string
+	for _, c := range cars {
+		if _, ok := done[c.RegNum]; !ok {
+			skipped = append(skipped, c.RegNum)
+		}
+	}
+	return skipped
+}
@@ -0,0 +1,45 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	mod "github.com/mi-raf/cars-catalog/internal/models"
+	"github.com/mi-raf/cars-catalog/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func importRow(line int, regNum string) mod.ImportRow {
+	return mod.ImportRow{Line: line, Car: mod.CarDTO{RegNum: regNum, Mark: "Lada", Model: "Vesta", Owner: &mod.PeopleDTO{Name: "Ivan", Surname: "Scott"}}}
+}
+
+func TestImportReportsFailedBatch(t *testing.T) {
+	r := &fakeRepo{failOn: 2}
+	s := newService(t, r, service.Options{Import: &service.ImportConfig{BatchSize: 2}})
+	rows := []mod.ImportRow{importRow(2, "A001AA77"), importRow(3, "A002AA77"), importRow(4, "A003AA77"), importRow(5, "A004AA77"), importRow(6, "A005AA77")}
+
+	report, err := s.Import(context.Background(), rows, false)
+
+	require.Error(t, err)
+	assert.Equal(t, []string{"A001AA77", "A002AA77"}, report.Imported)
+	require.NotNil(t, report.Failed)
+	assert.Equal(t, 4, report.Failed.FromLine)
+	assert.Equal(t, 5, report.Failed.ToLine)
+	assert.Equal(t, 2, r.batches, "batches after the failed one are not attempted")
+}
+
+func TestImportRejectsOverlongValues(t *testing.T) {
+	r := &fakeRepo{}
+	s := newService(t, r, service.Options{Import: &service.ImportConfig{BatchSize: 10}})
+	long := importRow(3, "A002AA77")
+	long.Car.Owner.Name = "Konstantin-Maximilian"
+
+	report, err := s.Import(context.Background(), []mod.ImportRow{importRow(2, "A001AA77"), long}, false)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"A001AA77"}, report.Imported)
+	require.Len(t, report.Errors, 1)
+	assert.Equal(t, 3, report.Errors[0].Line)
+	assert.Nil(t, report.Failed)
+}
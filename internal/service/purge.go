@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+type (
+	PurgeConfig struct {
+		// Retention is how long soft deleted cars are kept. Zero disables purging.
+		Retention time.Duration
+		Interval  time.Duration
+	}
+
+	// PurgeJob periodically hard deletes cars whose retention has expired.
+	PurgeJob struct {
+		s   *CarServise
+		cfg *PurgeConfig
+	}
+)
+
+func NewPurgeJob(s *CarServise, cfg *PurgeConfig) *PurgeJob {
+	return &PurgeJob{s: s, cfg: cfg}
+}
+
+// Run purges expired cars every interval until ctx is done.
+func (p *PurgeJob) Run(ctx context.Context) {
+	if p.cfg.Retention <= 0 || p.cfg.Interval <= 0 {
+		log.Info().Msg("purge of deleted cars is disabled")
+		return
+	}
+	log.Info().Dur("retention", p.cfg.Retention).Dur("interval", p.cfg.Interval).Msg("start purge job")
+
+	t := time.NewTicker(p.cfg.Interval)
+	defer t.Stop()
+	for {
+		p.purge(ctx)
+		select {
+		case <-ctx.Done():
+			log.Debug().Msg("stop purge job")
+			return
+		case <-t.C:
+		}
+	}
+}
+
+func (p *PurgeJob) purge(ctx context.Context) {
+	before := time.Now().Add(-p.cfg.Retention)
+	n, err := p.s.Purge(ctx, before)
+	if err != nil {
+		log.Error().Err(err).Msg("can't purge deleted cars")
+		return
+	}
+	log.Info().Int64("cars", n).Time("before", before).Msg("purged deleted cars")
+}
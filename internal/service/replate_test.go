@@ -0,0 +1,50 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mi-raf/cars-catalog/internal"
+	mod "github.com/mi-raf/cars-catalog/internal/models"
+	"github.com/mi-raf/cars-catalog/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubResourcesByOldPlate(t *testing.T) {
+	ctx := context.Background()
+	r := &fakeRepo{old: "A001AA77", current: "B002BB77"}
+	s := newService(t, r, service.Options{})
+
+	owners, err := s.Owners(ctx, "a001aa77")
+	require.NoError(t, err)
+	assert.Len(t, owners, 1)
+
+	require.NoError(t, s.AddMileage(ctx, "B002BB77", mod.MileageReading{Mileage: 1000, ReadOn: time.Now()}, false))
+	readings, err := s.Mileage(ctx, "A001AA77")
+	require.NoError(t, err)
+	assert.Len(t, readings, 1)
+
+	plates, err := s.Plates(ctx, "A001AA77")
+	require.NoError(t, err)
+	assert.Equal(t, []mod.PlateChange{{OldRegNum: "A001AA77", NewRegNum: "B002BB77"}}, plates)
+}
+
+func TestWritesByOldPlateAreNotFound(t *testing.T) {
+	ctx := context.Background()
+	r := &fakeRepo{old: "A001AA77", current: "B002BB77"}
+	s := newService(t, r, service.Options{})
+
+	assert.ErrorIs(t, s.AddMileage(ctx, "A001AA77", mod.MileageReading{Mileage: 1000, ReadOn: time.Now()}, false), internal.ErrNotFound)
+	assert.ErrorIs(t, s.Transfer(ctx, "A001AA77", &mod.PeopleDTO{Name: "Ivan", Surname: "Scott"}, 0), internal.ErrNotFound)
+	assert.Empty(t, r.readings)
+}
+
+func TestUnknownPlateIsNotFound(t *testing.T) {
+	s := newService(t, &fakeRepo{old: "A001AA77", current: "B002BB77"}, service.Options{})
+
+	_, err := s.Owners(context.Background(), "C003CC77")
+
+	assert.ErrorIs(t, err, internal.ErrNotFound)
+}
@@ -2,35 +2,303 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/mi-raf/cars-catalog/internal"
+	"github.com/mi-raf/cars-catalog/internal/cache"
 	"github.com/mi-raf/cars-catalog/internal/database"
 	mod "github.com/mi-raf/cars-catalog/internal/models"
+	"github.com/mi-raf/cars-catalog/internal/plate"
 	"github.com/mi-raf/cars-catalog/internal/swagger"
+	"github.com/mi-raf/cars-catalog/internal/vin"
 	"github.com/rs/zerolog/log"
 )
 
+const DEFAULT_MAX_DELETE_MATCHES = 1000
+
 type (
 	CarServise struct {
-		r   database.CarRepository
-		cli *swagger.APIClient
-		v   *validator.Validate
+		r         database.CarRepository
+		cli       *swagger.APIClient
+		v         *validator.Validate
+		cache     *CarListCache
+		importCfg *ImportConfig
+		plates    *plate.Registry
+		vinCfg    *VINConfig
+		deleteCfg *BatchDeleteConfig
+	}
+
+	// Options are the optional parts of the car service; a nil field turns the
+	// feature off or falls back to its default.
+	Options struct {
+		Cache       *CarListCache
+		Import      *ImportConfig
+		Plates      *plate.Registry
+		VIN         *VINConfig
+		BatchDelete *BatchDeleteConfig
+	}
+
+	BatchDeleteConfig struct {
+		// MaxMatches is the most cars a batch delete by filter may match.
+		MaxMatches int
 	}
+
+	// CarListCache keeps results of GetAll keyed by normalized filter and paging.
+	CarListCache = cache.LRU[string, []mod.CarDTO]
 )
 
-func NewCarService(r database.CarRepository, cli *swagger.APIClient, v *validator.Validate) *CarServise {
+// NewCarListCache returns nil when size is not positive, which disables caching.
+func NewCarListCache(size int, ttl time.Duration) *CarListCache {
+	if size < 1 {
+		return nil
+	}
+	return cache.New[string, []mod.CarDTO](size, ttl)
+}
+
+func NewCarService(r database.CarRepository, cli *swagger.APIClient, v *validator.Validate, opts Options) *CarServise {
 	log.Debug().Msg("create car service")
-	return &CarServise{r: r, cli: cli, v: v}
+	return &CarServise{r: r, cli: cli, v: v, cache: opts.Cache, importCfg: opts.Import, plates: opts.Plates, vinCfg: opts.VIN, deleteCfg: opts.BatchDelete}
 }
 
-func (c *CarServise) Delete(ctx context.Context, regNum string) error {
+func (c *CarServise) Delete(ctx context.Context, regNum string, version int32) error {
+	regNum = plate.Normalize(regNum)
 	log.Debug().Msg("delete car in service")
-	return c.r.Delete(ctx, regNum)
+	defer c.invalidate()
+	return c.r.Delete(ctx, regNum, version)
+}
+
+func (c *CarServise) Restore(ctx context.Context, regNum string) error {
+	regNum = plate.Normalize(regNum)
+	log.Debug().Str("reg num", regNum).Msg("restore car in service")
+	defer c.invalidate()
+	return c.r.Restore(ctx, regNum)
+}
+
+// Purge hard deletes cars which were soft deleted before the given time.
+func (c *CarServise) Purge(ctx context.Context, before time.Time) (int64, error) {
+	defer c.invalidate()
+	return c.r.Purge(ctx, before)
+}
+
+func (c *CarServise) DeleteBatch(ctx context.Context, req mod.BatchDelete) ([]mod.DeleteResult, error) {
+	log.Debug().Interface("request", req).Msg("batch delete in service")
+	if req.Filter != nil {
+		f := normalizeFilter(*req.Filter)
+		if isEmptyFilter(f) {
+			log.Debug().Msg("empty filter for batch delete")
+			return nil, internal.ErrEmptyFilter
+		}
+		req.Filter = &f
+		req.MaxMatches = c.maxDeleteMatches()
+	}
+	regNums := make([]string, 0, len(req.RegNums))
+	for _, r := range req.RegNums {
+		regNums = append(regNums, plate.Normalize(r))
+	}
+	req.RegNums = regNums
+	if !req.DryRun {
+		defer c.invalidate()
+	}
+	return c.r.DeleteBatch(ctx, req)
+}
+
+func (c *CarServise) maxDeleteMatches() int {
+	if c.deleteCfg == nil || c.deleteCfg.MaxMatches < 1 {
+		return DEFAULT_MAX_DELETE_MATCHES
+	}
+	return c.deleteCfg.MaxMatches
+}
+
+func (c *CarServise) History(ctx context.Context, regNum string) ([]mod.AuditRecord, error) {
+	regNum = plate.Normalize(regNum)
+	log.Debug().Str("reg num", regNum).Msg("get car history in service")
+	return c.r.History(ctx, regNum)
+}
+
+func (c *CarServise) AuditSince(ctx context.Context, since time.Time, limit int) ([]mod.AuditRecord, error) {
+	log.Debug().Time("since", since).Int("limit", limit).Msg("get audit in service")
+	return c.r.AuditSince(ctx, since, limit)
 }
 
-func (c *CarServise) AddAll(ctx context.Context, regNums []string) error {
+// Transfer hands the car over to a new owner, keeping the ownership history.
+func (c *CarServise) Transfer(ctx context.Context, regNum string, owner *mod.PeopleDTO, version int32) error {
+	regNum = plate.Normalize(regNum)
+	if err := c.v.Struct(owner); err != nil {
+		log.Error().Err(err).Msg("can't validate new owner")
+		return err
+	}
+	log.Debug().Str("reg num", regNum).Interface("owner", owner).Msg("transfer car")
+	defer c.invalidate()
+	return c.r.Transfer(ctx, regNum, owner, version)
+}
+
+// Replate gives the car a new registration number keeping its id and history.
+func (c *CarServise) Replate(ctx context.Context, regNum, newRegNum string, version int32) error {
+	req := mod.Replate{RegNum: plate.Normalize(regNum), NewRegNum: plate.Normalize(newRegNum), Version: version}
+	if err := c.v.Var(req.NewRegNum, "required,plate"); err != nil {
+		log.Error().Err(err).Msg("can't validate new plate")
+		return err
+	}
+	if req.RegNum == req.NewRegNum {
+		return internal.ErrSamePlate
+	}
+	if m, ok := c.plates.Detect(req.NewRegNum); ok {
+		req.PlateType = m.Type
+		req.RegionCode = m.Region
+	}
+	log.Debug().Interface("replate", req).Msg("replate car")
+	defer c.invalidate()
+	return c.r.Replate(ctx, req)
+}
+
+// Plates returns the plate changes of the car; an old plate of the car gives
+// the same changes as the current one.
+func (c *CarServise) Plates(ctx context.Context, regNum string) ([]mod.PlateChange, error) {
+	regNum = plate.Normalize(regNum)
+	log.Debug().Str("reg num", regNum).Msg("get plates in service")
+	changes, err := c.r.Plates(ctx, regNum)
+	if err != nil || len(changes) > 0 {
+		return changes, err
+	}
+	// nothing is known of a plate the car no longer has
+	current, err := c.r.CurrentPlate(ctx, regNum)
+	if err != nil {
+		if errors.Is(err, internal.ErrNotFound) {
+			return changes, nil
+		}
+		return nil, err
+	}
+	return c.r.Plates(ctx, current)
+}
+
+// CurrentPlate finds the plate of the car which used to be registered as regNum.
+func (c *CarServise) CurrentPlate(ctx context.Context, regNum string) (string, error) {
+	return c.r.CurrentPlate(ctx, plate.Normalize(regNum))
+}
+
+// onCurrentPlate runs the read fn for the car registered as regNum. When there
+// is no such car fn is run once more for the car which was re-plated from
+// regNum, so sub-resources of a car can still be read by its old plate. Writes
+// are not retried: a client writing by a stale plate gets internal.ErrNotFound.
+func (c *CarServise) onCurrentPlate(ctx context.Context, regNum string, fn func(regNum string) error) error {
+	err := fn(regNum)
+	if !errors.Is(err, internal.ErrNotFound) {
+		return err
+	}
+	current, cerr := c.r.CurrentPlate(ctx, regNum)
+	if cerr != nil {
+		if !errors.Is(cerr, internal.ErrNotFound) {
+			log.Error().Err(cerr).Str("reg num", regNum).Msg("can't find current plate")
+		}
+		return err
+	}
+	log.Debug().Str("reg num", regNum).Str("current", current).Msg("use current plate")
+	return fn(current)
+}
+
+func (c *CarServise) Owners(ctx context.Context, regNum string) (owners []mod.Ownership, err error) {
+	regNum = plate.Normalize(regNum)
+	log.Debug().Str("reg num", regNum).Msg("get owners in service")
+	err = c.onCurrentPlate(ctx, regNum, func(regNum string) error {
+		owners, err = c.r.Owners(ctx, regNum)
+		return err
+	})
+	return owners, err
+}
+
+// AddMileage logs an odometer reading; see the repository for the order check.
+func (c *CarServise) AddMileage(ctx context.Context, regNum string, reading mod.MileageReading, override bool) error {
+	regNum = plate.Normalize(regNum)
+	if err := c.v.Struct(reading); err != nil {
+		log.Error().Err(err).Msg("can't validate mileage reading")
+		return err
+	}
+	log.Debug().Str("reg num", regNum).Interface("reading", reading).Bool("override", override).Msg("add mileage")
+	defer c.invalidate()
+	return c.r.AddMileage(ctx, regNum, reading, override)
+}
+
+func (c *CarServise) Mileage(ctx context.Context, regNum string) (readings []mod.MileageReading, err error) {
+	regNum = plate.Normalize(regNum)
+	log.Debug().Str("reg num", regNum).Msg("get mileage in service")
+	err = c.onCurrentPlate(ctx, regNum, func(regNum string) error {
+		readings, err = c.r.Mileage(ctx, regNum)
+		return err
+	})
+	return readings, err
+}
+
+// AddMaintenance records a maintenance event of the car and fills in its id.
+func (c *CarServise) AddMaintenance(ctx context.Context, regNum string, m *mod.Maintenance) error {
+	regNum = plate.Normalize(regNum)
+	normalizeMaintenance(m)
+	if err := c.v.Struct(m); err != nil {
+		log.Error().Err(err).Msg("can't validate maintenance")
+		return err
+	}
+	log.Debug().Str("reg num", regNum).Interface("maintenance", m).Msg("add maintenance")
+	// listings may show the last service date
+	defer c.invalidate()
+	return c.r.AddMaintenance(ctx, regNum, m)
+}
+
+func (c *CarServise) GetMaintenance(ctx context.Context, regNum string, id int64) (m *mod.Maintenance, err error) {
+	err = c.onCurrentPlate(ctx, plate.Normalize(regNum), func(regNum string) error {
+		m, err = c.r.GetMaintenance(ctx, regNum, id)
+		return err
+	})
+	return m, err
+}
+
+func (c *CarServise) ListMaintenance(ctx context.Context, regNum string, filter mod.MaintenanceFilter) (records []mod.Maintenance, err error) {
+	regNum = plate.Normalize(regNum)
+	filter.WorkType = strings.TrimSpace(filter.WorkType)
+	log.Debug().Str("reg num", regNum).Interface("filter", filter).Msg("list maintenance")
+	err = c.onCurrentPlate(ctx, regNum, func(regNum string) error {
+		records, err = c.r.ListMaintenance(ctx, regNum, filter)
+		return err
+	})
+	return records, err
+}
+
+// UpdateMaintenance replaces the maintenance record m.ID of the car.
+func (c *CarServise) UpdateMaintenance(ctx context.Context, regNum string, m *mod.Maintenance) error {
+	regNum = plate.Normalize(regNum)
+	normalizeMaintenance(m)
+	if err := c.v.Struct(m); err != nil {
+		log.Error().Err(err).Msg("can't validate maintenance")
+		return err
+	}
+	log.Debug().Str("reg num", regNum).Interface("maintenance", m).Msg("update maintenance")
+	defer c.invalidate()
+	return c.r.UpdateMaintenance(ctx, regNum, m)
+}
+
+func (c *CarServise) DeleteMaintenance(ctx context.Context, regNum string, id int64) error {
+	regNum = plate.Normalize(regNum)
+	log.Debug().Str("reg num", regNum).Int64("id", id).Msg("delete maintenance")
+	defer c.invalidate()
+	return c.r.DeleteMaintenance(ctx, regNum, id)
+}
+
+// Get returns the car, or its state at asOf when it is not zero.
+func (c *CarServise) Get(ctx context.Context, regNum string, asOf time.Time) (*mod.CarDTO, error) {
+	regNum = plate.Normalize(regNum)
+	log.Debug().Str("reg num", regNum).Time("as of", asOf).Msg("get car in service")
+	if asOf.IsZero() {
+		return c.r.Get(ctx, regNum)
+	}
+	return c.r.GetAsOf(ctx, regNum, asOf)
+}
+
+// AddAll adds the cars known to the car API. With VIN checks enabled it also
+// returns the added cars whose mark does not match their VIN.
+func (c *CarServise) AddAll(ctx context.Context, regNums []string) ([]mod.VINMismatch, error) {
 	var carArr []mod.CarDTO
 
 	for _, r := range regNums {
@@ -39,19 +307,33 @@ func (c *CarServise) AddAll(ctx context.Context, regNums []string) error {
 
 		if err != nil {
 			log.Error().Err(err).Msg("can't get information from client")
-			return mapClientError(r, resp, err)
+			return nil, mapClientError(r, resp, err)
 
 		}
 		addCar := mapCar(car)
+		addCar.RegNum = plate.Normalize(addCar.RegNum)
 		if err = c.v.Struct(addCar); err != nil {
 			log.Error().Err(err).Msg("can't validate info from client")
-			return err
+			return nil, err
 		}
+		c.detectPlate(&addCar)
 		carArr = append(carArr, addCar)
 	}
 	log.Debug().Interface("car array", carArr).Msg("validated cars from api")
-	return c.r.Add(ctx, carArr)
+	defer c.invalidate()
+	if err := c.r.Add(ctx, carArr); err != nil {
+		return nil, err
+	}
+	return c.checkVINMarks(carArr), nil
+
+}
 
+// detectPlate fills the plate type and region code of a validated car.
+func (c *CarServise) detectPlate(car *mod.CarDTO) {
+	if m, ok := c.plates.Detect(car.RegNum); ok {
+		car.PlateType = m.Type
+		car.RegionCode = m.Region
+	}
 }
 
 func mapClientError(regNum string, resp *http.Response, err error) error {
@@ -78,6 +360,14 @@ func mapCar(c swagger.Car) mod.CarDTO {
 		Mark:   c.Mark,
 		Model:  c.Model,
 		Year:   c.Year,
+		VIN:    vin.Normalize(c.Vin),
+
+		Color:        strings.TrimSpace(c.Color),
+		BodyType:     c.BodyType,
+		Fuel:         c.Fuel,
+		EngineVolume: c.EngineVolume,
+		Transmission: c.Transmission,
+		Mileage:      c.Mileage,
 		Owner: &mod.PeopleDTO{
 			Name:       c.Owner.Name,
 			Surname:    c.Owner.Surname,
@@ -87,16 +377,172 @@ func mapCar(c swagger.Car) mod.CarDTO {
 }
 
 func (c *CarServise) Update(ctx context.Context, car *mod.CarDTO) error {
-	err := c.v.Struct(car)
+	car.RegNum = plate.Normalize(car.RegNum)
+	car.VIN = vin.Normalize(car.VIN)
+	// the plate only identifies the car here and is not changed, so cars
+	// added before formats were validated can still be updated
+	err := c.v.StructExcept(car, "RegNum")
 	if err != nil {
 		log.Error().Err(err).Msg("can't validate for update")
 		return err
 	}
 	log.Debug().Interface("car", car).Msg("update car")
+	defer c.invalidate()
 	return c.r.Update(ctx, car)
 }
 
+// GetAll returns a page of cars. Results are served from the list cache when
+// it is enabled; callers must not modify the returned slice.
 func (c *CarServise) GetAll(ctx context.Context, filter mod.CarFilter, offset, limit int) ([]mod.CarDTO, error) {
+	filter = normalizeFilter(filter)
 	log.Debug().Interface("filter", filter).Msg("validated filter")
-	return c.r.GetAll(ctx, filter, offset, limit)
+	if c.cache == nil {
+		return c.getAll(ctx, filter, offset, limit)
+	}
+
+	key := listCacheKey(filter, offset, limit)
+	if cars, ok := c.cache.Get(key); ok {
+		log.Debug().Str("key", key).Msg("cars found in cache")
+		return cars, nil
+	}
+	// a write finished during the read may not be seen by it, such a result
+	// must not outlive the invalidation of the write
+	gen := c.cache.Generation()
+	cars, err := c.getAll(ctx, filter, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	if !c.cache.AddIf(gen, key, cars) {
+		log.Debug().Str("key", key).Msg("cache invalidated during read, result not cached")
+	}
+	return cars, nil
+}
+
+func (c *CarServise) getAll(ctx context.Context, filter mod.CarFilter, offset, limit int) ([]mod.CarDTO, error) {
+	cars, err := c.r.GetAll(ctx, filter, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	return cars, c.expand(ctx, filter, cars)
+}
+
+// expand fills in the parts of the cars the filter asked for.
+func (c *CarServise) expand(ctx context.Context, filter mod.CarFilter, cars []mod.CarDTO) error {
+	if !filter.LastService || len(cars) < 1 {
+		return nil
+	}
+	ids := make([]int64, 0, len(cars))
+	for _, car := range cars {
+		ids = append(ids, car.ID)
+	}
+	last, err := c.r.LastServices(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for i := range cars {
+		cars[i].LastServiceOn = last[cars[i].ID]
+	}
+	return nil
+}
+
+// ForEach streams all cars matching the filter to fn, bypassing the cache.
+func (c *CarServise) ForEach(ctx context.Context, filter mod.CarFilter, fn func(*mod.CarDTO) error) error {
+	filter = normalizeFilter(filter)
+	log.Debug().Interface("filter", filter).Msg("iterate cars")
+	return c.r.ForEach(ctx, filter, fn)
+}
+
+// GetAllWithFacets returns a page of cars and value counts for the facets.
+// Facet counts depend on the whole filter, so the result is not cached.
+func (c *CarServise) GetAllWithFacets(ctx context.Context, filter mod.CarFilter, offset, limit int, facets []string) ([]mod.CarDTO, map[string][]mod.StatGroup, error) {
+	filter = normalizeFilter(filter)
+	log.Debug().Interface("filter", filter).Strs("facets", facets).Msg("get cars with facets")
+	cars, counts, err := c.r.GetAllWithFacets(ctx, filter, offset, limit, facets)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cars, counts, c.expand(ctx, filter, cars)
+}
+
+func (c *CarServise) SuggestMarks(ctx context.Context, prefix string, limit int) ([]mod.StatGroup, error) {
+	return c.r.SuggestMarks(ctx, strings.TrimSpace(prefix), limit)
+}
+
+func (c *CarServise) SuggestModels(ctx context.Context, mark, prefix string, limit int) ([]mod.StatGroup, error) {
+	return c.r.SuggestModels(ctx, strings.TrimSpace(mark), strings.TrimSpace(prefix), limit)
+}
+
+// SuggestOwners collapses repeated spaces so that "Scott  Iv" still matches.
+func (c *CarServise) SuggestOwners(ctx context.Context, prefix string, limit int) ([]mod.StatGroup, error) {
+	return c.r.SuggestOwners(ctx, strings.Join(strings.Fields(prefix), " "), limit)
+}
+
+// Search finds active cars by free text, most relevant first.
+func (c *CarServise) Search(ctx context.Context, query string, offset, limit int) ([]mod.SearchHit, error) {
+	query = strings.Join(strings.Fields(query), " ")
+	if len(query) < 1 {
+		return nil, internal.ErrEmptyQuery
+	}
+	log.Debug().Str("query", query).Msg("search cars")
+	return c.r.Search(ctx, query, offset, limit)
+}
+
+// Stats counts cars matching the filter grouped by one dimension.
+func (c *CarServise) Stats(ctx context.Context, filter mod.CarFilter, q mod.StatsQuery) (mod.Stats, error) {
+	filter = normalizeFilter(filter)
+	log.Debug().Interface("filter", filter).Interface("query", q).Msg("get stats")
+	return c.r.Stats(ctx, filter, q)
+}
+
+// invalidate drops cached listings after a write. It runs even when the write
+// failed because a partially applied or concurrent change can not be ruled out.
+func (c *CarServise) invalidate() {
+	if c.cache != nil {
+		c.cache.Purge()
+	}
+}
+
+func normalizeFilter(f mod.CarFilter) mod.CarFilter {
+	f.RegNum = plate.Normalize(f.RegNum)
+	f.Mark = strings.TrimSpace(f.Mark)
+	f.Model = strings.TrimSpace(f.Model)
+	f.Name = strings.TrimSpace(f.Name)
+	f.Surname = strings.TrimSpace(f.Surname)
+	f.Patronymic = strings.TrimSpace(f.Patronymic)
+	f.Region = strings.TrimSpace(f.Region)
+	f.VIN = vin.Normalize(f.VIN)
+	f.Color = strings.TrimSpace(f.Color)
+	f.Status = strings.ToLower(strings.TrimSpace(f.Status))
+	f.Sort = strings.TrimSpace(f.Sort)
+	if !f.AsOf.IsZero() {
+		f.AsOf = f.AsOf.UTC()
+	}
+	return f
+}
+
+// isEmptyFilter reports whether the filter matches every car.
+func isEmptyFilter(f mod.CarFilter) bool {
+	f.IncludeDeleted = false
+	f.AsOf = time.Time{}
+	f.Sort = ""
+	f.LastService = false
+	return f == mod.CarFilter{}
+}
+
+func normalizeMaintenance(m *mod.Maintenance) {
+	m.WorkType = strings.TrimSpace(m.WorkType)
+	m.Notes = strings.TrimSpace(m.Notes)
+}
+
+// listCacheKey joins the normalized filter and the paging; strings are quoted
+// so that a separator inside a value can't make two keys equal.
+func listCacheKey(f mod.CarFilter, offset, limit int) string {
+	var asOf string
+	if !f.AsOf.IsZero() {
+		asOf = f.AsOf.UTC().Format(time.RFC3339Nano)
+	}
+	return fmt.Sprintf("%q|%q|%q|%d|%q|%q|%q|%q|%q|%q|%q|%q|%q|%d|%d|%d|%d|%q|%q|%t|%s|%t|%d|%d",
+		f.RegNum, f.Mark, f.Model, f.Year, f.Name, f.Surname, f.Patronymic, f.Region, f.VIN,
+		f.Color, f.BodyType, f.Fuel, f.Transmission, f.MileageMin, f.MileageMax, f.EngineVolumeMin, f.EngineVolumeMax, f.Status,
+		f.Sort, f.IncludeDeleted, asOf, f.LastService, offset, limit)
 }
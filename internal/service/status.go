@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mi-raf/cars-catalog/internal"
+	mod "github.com/mi-raf/cars-catalog/internal/models"
+	"github.com/mi-raf/cars-catalog/internal/plate"
+	"github.com/rs/zerolog/log"
+)
+
+// statusTransitions lists the statuses a car may move to from each status.
+// A scrapped car is gone for good; an exported or recovered car becomes
+// active again.
+var statusTransitions = map[string][]string{
+	mod.StatusActive:   {mod.StatusSold, mod.StatusStolen, mod.StatusScrapped, mod.StatusExported},
+	mod.StatusSold:     {mod.StatusActive, mod.StatusStolen, mod.StatusScrapped, mod.StatusExported},
+	mod.StatusStolen:   {mod.StatusActive, mod.StatusScrapped},
+	mod.StatusExported: {mod.StatusActive},
+	mod.StatusScrapped: nil,
+}
+
+// KnownStatus reports whether s is one of the lifecycle statuses.
+func KnownStatus(s string) bool {
+	_, ok := statusTransitions[s]
+	return ok
+}
+
+func canTransit(from, to string) bool {
+	for _, s := range statusTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// ChangeStatus moves the car to a new lifecycle status. Transitions outside
+// statusTransitions are refused with internal.ErrBadTransition.
+func (c *CarServise) ChangeStatus(ctx context.Context, regNum, status, reason string, version int32) error {
+	req := mod.StatusChange{
+		RegNum:  plate.Normalize(regNum),
+		To:      strings.ToLower(strings.TrimSpace(status)),
+		Reason:  strings.TrimSpace(reason),
+		Version: version,
+	}
+	if err := c.v.Struct(req); err != nil {
+		log.Error().Err(err).Msg("can't validate status change")
+		return err
+	}
+	defer c.invalidate()
+	car, err := c.r.Get(ctx, req.RegNum)
+	if err != nil {
+		return err
+	}
+	req.From = car.Status
+	if !canTransit(req.From, req.To) {
+		log.Debug().Str("reg num", req.RegNum).Str("from", req.From).Str("to", req.To).Msg("status transition not allowed")
+		return fmt.Errorf("%w: %s to %s", internal.ErrBadTransition, req.From, req.To)
+	}
+	log.Debug().Str("reg num", req.RegNum).Interface("change", req).Msg("change status")
+	return c.r.ChangeStatus(ctx, req)
+}
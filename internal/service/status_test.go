@@ -0,0 +1,29 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mi-raf/cars-catalog/internal"
+	mod "github.com/mi-raf/cars-catalog/internal/models"
+	"github.com/mi-raf/cars-catalog/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScrappedIsTerminal(t *testing.T) {
+	s := newService(t, &fakeRepo{current: "A001AA77", status: mod.StatusScrapped}, service.Options{})
+
+	err := s.ChangeStatus(context.Background(), "A001AA77", mod.StatusActive, "found in a barn", 0)
+
+	require.Error(t, err)
+	assert.ErrorIs(t, err, internal.ErrBadTransition)
+}
+
+func TestKnownStatus(t *testing.T) {
+	for _, s := range []string{mod.StatusActive, mod.StatusSold, mod.StatusStolen, mod.StatusScrapped, mod.StatusExported} {
+		assert.True(t, service.KnownStatus(s), s)
+	}
+	assert.False(t, service.KnownStatus("bogus"))
+	assert.False(t, service.KnownStatus(""))
+}
@@ -0,0 +1,34 @@
+package service
+
+import (
+	mod "github.com/mi-raf/cars-catalog/internal/models"
+	"github.com/mi-raf/cars-catalog/internal/vin"
+	"github.com/rs/zerolog/log"
+)
+
+type VINConfig struct {
+	// CheckMark compares the mark of cars added from the car API with the
+	// manufacturer decoded from their VIN.
+	CheckMark bool
+}
+
+// checkVINMarks returns the cars whose mark differs from the manufacturer the
+// VIN was issued to. Cars are added anyway, the mismatches are only reported.
+func (c *CarServise) checkVINMarks(cars []mod.CarDTO) []mod.VINMismatch {
+	if c.vinCfg == nil || !c.vinCfg.CheckMark {
+		return nil
+	}
+	var res []mod.VINMismatch
+	for _, car := range cars {
+		if len(car.VIN) < 1 {
+			continue
+		}
+		info, err := vin.Decode(car.VIN)
+		if err != nil || vin.SameMark(info.WMI, car.Mark) {
+			continue
+		}
+		log.Warn().Str("reg num", car.RegNum).Str("vin", car.VIN).Str("mark", car.Mark).Str("decoded", info.Mark).Msg("mark does not match vin")
+		res = append(res, mod.VINMismatch{RegNum: car.RegNum, VIN: car.VIN, Mark: car.Mark, DecodedMark: info.Mark})
+	}
+	return res
+}
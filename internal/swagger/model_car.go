@@ -1,10 +1,9 @@
 /*
  * Car info
  *
- * No description provided (generated by Swagger Codegen https://github.com/swagger-api/swagger-codegen)
- *
  * API version: 0.0.1
- * Generated by: Swagger Codegen (https://github.com/swagger-api/swagger-codegen.git)
+ * Maintained by hand: the fields after Year are not in the spec the rest of
+ * the client was generated from, regenerating would drop them.
  */
 package swagger
 
@@ -13,5 +12,12 @@ type Car struct {
 	Mark string `json:"mark"`
 	Model string `json:"model"`
 	Year int32 `json:"year,omitempty"`
+	Vin string `json:"vin,omitempty"`
+	Color string `json:"color,omitempty"`
+	BodyType string `json:"bodyType,omitempty"`
+	Fuel string `json:"fuel,omitempty"`
+	EngineVolume int32 `json:"engineVolume,omitempty"`
+	Transmission string `json:"transmission,omitempty"`
+	Mileage int32 `json:"mileage,omitempty"`
 	Owner *People `json:"owner"`
 }
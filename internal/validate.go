@@ -1,9 +1,12 @@
 package internal
 
 import (
+	"reflect"
 	"time"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/mi-raf/cars-catalog/internal/plate"
+	"github.com/mi-raf/cars-catalog/internal/vin"
 	"github.com/rs/zerolog/log"
 )
 
@@ -23,3 +26,28 @@ func LessThanCurrYearValidator(fl validator.FieldLevel) bool {
 	}
 	return true
 }
+
+func VINValidator(fl validator.FieldLevel) bool {
+	if fl.Field().Kind() != reflect.String {
+		return false
+	}
+	if !vin.Valid(fl.Field().String()) {
+		log.Debug().Str("vin", fl.Field().String()).Msg("invalid vin")
+		return false
+	}
+	return true
+}
+
+// PlateValidator accepts registration numbers of any format known to the registry.
+func PlateValidator(r *plate.Registry) validator.Func {
+	return func(fl validator.FieldLevel) bool {
+		if fl.Field().Kind() != reflect.String {
+			return false
+		}
+		if _, ok := r.Detect(fl.Field().String()); !ok {
+			log.Debug().Str("plate", fl.Field().String()).Msg("unknown plate format")
+			return false
+		}
+		return true
+	}
+}
@@ -0,0 +1,121 @@
+package vin
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// yearPos is the index of the model year character
+	yearPos = 9
+	// cyclePos is the index of the character telling the model year cycles apart
+	cyclePos = 6
+
+	yearCycle = 30
+)
+
+// yearChars lists the model year characters starting from 1980 (and 2010).
+const yearChars = "ABCDEFGHJKLMNPRSTVWXY123456789"
+
+// wmiJSON is the table of world manufacturer identifiers.
+//
+//go:embed wmi.json
+var wmiJSON []byte
+
+var manufacturers = mustLoadWMI(wmiJSON)
+
+type (
+	// Info is what can be told about a car from its VIN alone.
+	Info struct {
+		WMI string
+		// Mark and Country are empty when the WMI is not in the table
+		Mark      string
+		Country   string
+		ModelYear int
+	}
+
+	manufacturer struct {
+		Mark    string   `json:"mark"`
+		Aliases []string `json:"aliases"`
+		Country string   `json:"country"`
+		WMI     []string `json:"wmi"`
+	}
+
+	wmiTable struct {
+		Version       string         `json:"version"`
+		Manufacturers []manufacturer `json:"manufacturers"`
+
+		byWMI map[string]*manufacturer
+	}
+)
+
+func mustLoadWMI(data []byte) *wmiTable {
+	t := &wmiTable{}
+	if err := json.Unmarshal(data, t); err != nil {
+		panic(fmt.Sprintf("vin: can't read WMI table: %v", err))
+	}
+	t.byWMI = make(map[string]*manufacturer)
+	for i := range t.Manufacturers {
+		m := &t.Manufacturers[i]
+		for _, w := range m.WMI {
+			if prev, ok := t.byWMI[w]; ok {
+				panic(fmt.Sprintf("vin: WMI %s belongs to %s and %s", w, prev.Mark, m.Mark))
+			}
+			t.byWMI[w] = m
+		}
+	}
+	return t
+}
+
+// Decode tells the manufacturer and model year of a valid VIN without calling
+// any service. The model year character repeats every 30 years; a digit in
+// the seventh position means 1980-2009 and a letter 2010-2039, as in North
+// America. Years more than one year ahead are moved a cycle back.
+func Decode(v string) (Info, error) {
+	if !Valid(v) {
+		return Info{}, fmt.Errorf("invalid vin: %q", v)
+	}
+	info := Info{WMI: v[:3]}
+	if m, ok := manufacturers.byWMI[info.WMI]; ok {
+		info.Mark = m.Mark
+		info.Country = m.Country
+	}
+	if i := strings.IndexByte(yearChars, v[yearPos]); i >= 0 {
+		year := 1980 + i
+		if c := v[cyclePos]; c < '0' || c > '9' {
+			year += yearCycle
+		}
+		if year > time.Now().Year()+1 {
+			year -= yearCycle
+		}
+		info.ModelYear = year
+	}
+	return info, nil
+}
+
+// SameMark reports whether mark names the manufacturer the WMI was issued to.
+// It is true when the WMI is unknown, as nothing can be said then.
+func SameMark(wmi, mark string) bool {
+	m, ok := manufacturers.byWMI[wmi]
+	if !ok {
+		return true
+	}
+	mark = strings.TrimSpace(mark)
+	if strings.EqualFold(m.Mark, mark) {
+		return true
+	}
+	for _, a := range m.Aliases {
+		if strings.EqualFold(a, mark) {
+			return true
+		}
+	}
+	return false
+}
+
+// WMIVersion is the version of the embedded WMI table.
+func WMIVersion() string {
+	return manufacturers.Version
+}
@@ -0,0 +1,42 @@
+package vin_test
+
+import (
+	"testing"
+
+	"github.com/mi-raf/cars-catalog/internal/vin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecode(t *testing.T) {
+	cases := []struct {
+		vin, mark, country string
+		year               int
+	}{
+		{"1HGCM82633A004352", "Honda", "JP", 2003},
+		{"JH4KA7561PC008269", "Acura", "JP", 1993},
+		{"1M8GDM9AXKP042788", "MCI", "US", 1989},
+		{"XTA21990722765432", "Lada", "RU", 2002},
+		{"11111111111111111", "", "", 2001},
+	}
+	for _, c := range cases {
+		info, err := vin.Decode(c.vin)
+		require.NoError(t, err, c.vin)
+		assert.Equal(t, c.vin[:3], info.WMI, c.vin)
+		assert.Equal(t, c.mark, info.Mark, c.vin)
+		assert.Equal(t, c.country, info.Country, c.vin)
+		assert.Equal(t, c.year, info.ModelYear, c.vin)
+	}
+
+	_, err := vin.Decode("1HGCM82633A00435")
+	assert.Error(t, err)
+	assert.NotEmpty(t, vin.WMIVersion())
+}
+
+func TestSameMark(t *testing.T) {
+	assert.True(t, vin.SameMark("XTA", "LADA"))
+	assert.True(t, vin.SameMark("XTA", " vaz "))
+	assert.True(t, vin.SameMark("WVW", "VW"))
+	assert.False(t, vin.SameMark("XTA", "BMW"))
+	assert.True(t, vin.SameMark("ZZZ", "anything"))
+}
@@ -0,0 +1,60 @@
+// Package vin validates vehicle identification numbers.
+package vin
+
+import "strings"
+
+const (
+	Length = 17
+
+	// checkPos is the index of the check digit
+	checkPos = 8
+)
+
+var weights = [Length]int{8, 7, 6, 5, 4, 3, 2, 10, 0, 9, 8, 7, 6, 5, 4, 3, 2}
+
+// Normalize trims the VIN and turns it upper case.
+func Normalize(v string) string {
+	return strings.ToUpper(strings.TrimSpace(v))
+}
+
+// Valid reports whether v is a normalized VIN of 17 characters without I, O
+// and Q whose ninth character is the check digit computed as in ISO 3779
+// (49 CFR 565): transliterated characters weighted by position, modulo 11,
+// with 10 written as X.
+func Valid(v string) bool {
+	if len(v) != Length {
+		return false
+	}
+	sum := 0
+	for i := 0; i < Length; i++ {
+		n, ok := value(v[i])
+		if !ok {
+			return false
+		}
+		sum += n * weights[i]
+	}
+	check := byte('0' + sum%11)
+	if sum%11 == 10 {
+		check = 'X'
+	}
+	return v[checkPos] == check
+}
+
+// value transliterates a VIN character, letters I, O and Q are not allowed.
+func value(c byte) (int, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c - '0'), true
+	case c >= 'A' && c <= 'H':
+		return int(c-'A') + 1, true
+	case c >= 'J' && c <= 'N':
+		return int(c-'J') + 1, true
+	case c == 'P':
+		return 7, true
+	case c == 'R':
+		return 9, true
+	case c >= 'S' && c <= 'Z':
+		return int(c-'S') + 2, true
+	}
+	return 0, false
+}
@@ -0,0 +1,28 @@
+package vin_test
+
+import (
+	"testing"
+
+	"github.com/mi-raf/cars-catalog/internal/vin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValid(t *testing.T) {
+	for _, v := range []string{"1M8GDM9AXKP042788", "11111111111111111", "1HGCM82633A004352", "JH4KA7561PC008269"} {
+		assert.True(t, vin.Valid(v), v)
+	}
+	for _, v := range []string{
+		"",
+		"1M8GDM9AXKP04278",   // too short
+		"1M8GDM9AXKP0427888", // too long
+		"1M8GDM9A1KP042788",  // wrong check digit
+		"1M8GDM9AXKP04278O",  // O is not allowed
+		"1m8gdm9axkp042788",  // not normalized
+	} {
+		assert.False(t, vin.Valid(v), v)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, "1M8GDM9AXKP042788", vin.Normalize(" 1m8gdm9axkp042788\n"))
+}
@@ -0,0 +1,280 @@
+// Package xlsx writes simple Office Open XML workbooks. Sheets are streamed
+// one after another straight into the zip archive, so only the current row is
+// kept in memory.
+package xlsx
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	MAX_SHEET_NAME = 31
+
+	styleDefault = 0
+	styleBold    = 1
+)
+
+var (
+	ErrClosed         = errors.New("workbook is closed")
+	ErrSheetClosed    = errors.New("sheet is closed")
+	ErrBadSheetName   = errors.New("incorrect sheet name")
+	ErrDuplicateSheet = errors.New("sheet already exists")
+)
+
+type (
+	// Cell is one value of a row. The zero value is an empty cell.
+	Cell struct {
+		kind  cellKind
+		str   string
+		num   float64
+		style int
+	}
+
+	cellKind int
+
+	SheetOptions struct {
+		// Widths of the columns in characters starting from the first one;
+		// zero keeps the default width.
+		Widths []float64
+		// FreezeHeader keeps the first row visible while scrolling.
+		FreezeHeader bool
+	}
+
+	Writer struct {
+		zw     *zip.Writer
+		sheets []string
+		cur    *Sheet
+		closed bool
+	}
+
+	Sheet struct {
+		w      *bufio.Writer
+		row    int
+		closed bool
+	}
+)
+
+const (
+	kindEmpty cellKind = iota
+	kindString
+	kindNumber
+)
+
+func String(s string) Cell {
+	return Cell{kind: kindString, str: s}
+}
+
+func Number(n float64) Cell {
+	return Cell{kind: kindNumber, num: n}
+}
+
+func Int(n int64) Cell {
+	return Number(float64(n))
+}
+
+// Bold returns a string cell rendered in bold font.
+func Bold(s string) Cell {
+	return Cell{kind: kindString, str: s, style: styleBold}
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{zw: zip.NewWriter(w)}
+}
+
+// NewSheet finishes the current sheet, if any, and starts a new one.
+func (w *Writer) NewSheet(name string, opts SheetOptions) (*Sheet, error) {
+	if w.closed {
+		return nil, ErrClosed
+	}
+	if err := checkSheetName(name); err != nil {
+		return nil, err
+	}
+	for _, s := range w.sheets {
+		if strings.EqualFold(s, name) {
+			return nil, ErrDuplicateSheet
+		}
+	}
+	if err := w.closeSheet(); err != nil {
+		return nil, err
+	}
+
+	f, err := w.zw.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", len(w.sheets)+1))
+	if err != nil {
+		return nil, err
+	}
+	w.sheets = append(w.sheets, name)
+	s := &Sheet{w: bufio.NewWriter(f)}
+	w.cur = s
+
+	s.w.WriteString(xml.Header)
+	s.w.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	if opts.FreezeHeader {
+		s.w.WriteString(`<sheetViews><sheetView workbookViewId="0">` +
+			`<pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/>` +
+			`<selection pane="bottomLeft"/></sheetView></sheetViews>`)
+	}
+	if len(opts.Widths) > 0 {
+		s.w.WriteString(`<cols>`)
+		for i, width := range opts.Widths {
+			if width > 0 {
+				fmt.Fprintf(s.w, `<col min="%d" max="%d" width="%s" customWidth="1"/>`, i+1, i+1, strconv.FormatFloat(width, 'f', -1, 64))
+			}
+		}
+		s.w.WriteString(`</cols>`)
+	}
+	_, err = s.w.WriteString(`<sheetData>`)
+	return s, err
+}
+
+// WriteRow appends a row to the sheet.
+func (s *Sheet) WriteRow(cells ...Cell) error {
+	if s.closed {
+		return ErrSheetClosed
+	}
+	s.row++
+	fmt.Fprintf(s.w, `<row r="%d">`, s.row)
+	for i, c := range cells {
+		ref := ColumnName(i) + strconv.Itoa(s.row)
+		switch c.kind {
+		case kindString:
+			fmt.Fprintf(s.w, `<c r="%s" t="inlineStr"%s><is><t xml:space="preserve">`, ref, styleAttr(c.style))
+			if err := xml.EscapeText(s.w, []byte(c.str)); err != nil {
+				return err
+			}
+			s.w.WriteString(`</t></is></c>`)
+		case kindNumber:
+			fmt.Fprintf(s.w, `<c r="%s"%s><v>%s</v></c>`, ref, styleAttr(c.style), strconv.FormatFloat(c.num, 'f', -1, 64))
+		}
+	}
+	_, err := s.w.WriteString(`</row>`)
+	return err
+}
+
+// Header writes a row of bold strings.
+func (s *Sheet) Header(names ...string) error {
+	cells := make([]Cell, len(names))
+	for i, n := range names {
+		cells[i] = Bold(n)
+	}
+	return s.WriteRow(cells...)
+}
+
+func (s *Sheet) close() error {
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	s.w.WriteString(`</sheetData></worksheet>`)
+	return s.w.Flush()
+}
+
+func (w *Writer) closeSheet() error {
+	if w.cur == nil {
+		return nil
+	}
+	err := w.cur.close()
+	w.cur = nil
+	return err
+}
+
+// Close finishes the last sheet and writes the workbook parts. It does not
+// close the underlying writer.
+func (w *Writer) Close() error {
+	if w.closed {
+		return ErrClosed
+	}
+	if err := w.closeSheet(); err != nil {
+		return err
+	}
+	w.closed = true
+	if len(w.sheets) < 1 {
+		return errors.New("workbook must have at least one sheet")
+	}
+
+	var ct, wb, rels strings.Builder
+	ct.WriteString(xml.Header)
+	ct.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	wb.WriteString(xml.Header)
+	wb.WriteString(`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	rels.WriteString(xml.Header)
+	rels.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i, name := range w.sheets {
+		n := i + 1
+		fmt.Fprintf(&ct, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, n)
+		wb.WriteString(`<sheet name="`)
+		xml.EscapeText(&wb, []byte(name))
+		fmt.Fprintf(&wb, `" sheetId="%d" r:id="rId%d"/>`, n, n)
+		fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, n, n)
+	}
+	ct.WriteString(`</Types>`)
+	wb.WriteString(`</sheets></workbook>`)
+	fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, len(w.sheets)+1)
+	rels.WriteString(`</Relationships>`)
+
+	parts := []struct{ name, body string }{
+		{"[Content_Types].xml", ct.String()},
+		{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`},
+		{"xl/workbook.xml", wb.String()},
+		{"xl/_rels/workbook.xml.rels", rels.String()},
+		{"xl/styles.xml", stylesXML},
+	}
+	for _, p := range parts {
+		f, err := w.zw.Create(p.name)
+		if err != nil {
+			return err
+		}
+		if _, err = io.WriteString(f, p.body); err != nil {
+			return err
+		}
+	}
+	return w.zw.Close()
+}
+
+// ColumnName converts a zero based column index to its letters: 0 is A, 26 is AA.
+func ColumnName(i int) string {
+	var b []byte
+	for i++; i > 0; i = (i - 1) / 26 {
+		b = append([]byte{byte('A' + (i-1)%26)}, b...)
+	}
+	return string(b)
+}
+
+func checkSheetName(name string) error {
+	if len(name) < 1 || len([]rune(name)) > MAX_SHEET_NAME || strings.ContainsAny(name, `[]:*?/\`) ||
+		strings.HasPrefix(name, "'") || strings.HasSuffix(name, "'") {
+		return fmt.Errorf("%w: %q", ErrBadSheetName, name)
+	}
+	return nil
+}
+
+func styleAttr(style int) string {
+	if style == styleDefault {
+		return ""
+	}
+	return ` s="` + strconv.Itoa(style) + `"`
+}
+
+// stylesXML declares the cell formats referenced by styleDefault and styleBold.
+const stylesXML = xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>` +
+	`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>` +
+	`</styleSheet>`
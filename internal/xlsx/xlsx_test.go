@@ -0,0 +1,76 @@
+package xlsx_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"testing"
+
+	"github.com/mi-raf/cars-catalog/internal/xlsx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestColumnName(t *testing.T) {
+	assert.Equal(t, "A", xlsx.ColumnName(0))
+	assert.Equal(t, "Z", xlsx.ColumnName(25))
+	assert.Equal(t, "AA", xlsx.ColumnName(26))
+	assert.Equal(t, "AZ", xlsx.ColumnName(51))
+	assert.Equal(t, "BA", xlsx.ColumnName(52))
+}
+
+func TestWriterProducesWellFormedParts(t *testing.T) {
+	var buf bytes.Buffer
+	w := xlsx.NewWriter(&buf)
+	s, err := w.NewSheet("Cars", xlsx.SheetOptions{Widths: []float64{12, 0, 8}, FreezeHeader: true})
+	require.NoError(t, err)
+	require.NoError(t, s.Header("Reg num", "Mark", "Year"))
+	require.NoError(t, s.WriteRow(xlsx.String("а123вс77"), xlsx.String(`<"Лада" & co>`), xlsx.Int(2020)))
+	_, err = w.NewSheet("Summary", xlsx.SheetOptions{})
+	require.NoError(t, err)
+	assert.ErrorIs(t, s.WriteRow(xlsx.String("late")), xlsx.ErrSheetClosed)
+	require.NoError(t, w.Close())
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	files := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		data, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		rc.Close()
+		files[f.Name] = string(data)
+
+		d := xml.NewDecoder(bytes.NewReader(data))
+		for {
+			_, err := d.Token()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err, f.Name)
+		}
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml", "xl/worksheets/sheet1.xml", "xl/worksheets/sheet2.xml"} {
+		assert.Contains(t, files, name)
+	}
+	sheet := files["xl/worksheets/sheet1.xml"]
+	assert.Contains(t, sheet, `state="frozen"`)
+	assert.Contains(t, sheet, `<col min="1" max="1" width="12" customWidth="1"/>`)
+	assert.NotContains(t, sheet, `<col min="2"`)
+	assert.Contains(t, sheet, `<c r="C2"><v>2020</v></c>`)
+	assert.Contains(t, sheet, `<c r="A1" t="inlineStr" s="1">`)
+	assert.Contains(t, sheet, "а123вс77")
+	assert.Contains(t, files["xl/workbook.xml"], `<sheet name="Summary" sheetId="2" r:id="rId2"/>`)
+}
+
+func TestWriterRejectsBadSheetNames(t *testing.T) {
+	w := xlsx.NewWriter(io.Discard)
+	_, err := w.NewSheet("a/b", xlsx.SheetOptions{})
+	assert.ErrorIs(t, err, xlsx.ErrBadSheetName)
+	_, err = w.NewSheet("Cars", xlsx.SheetOptions{})
+	require.NoError(t, err)
+	_, err = w.NewSheet("cars", xlsx.SheetOptions{})
+	assert.ErrorIs(t, err, xlsx.ErrDuplicateSheet)
+}
@@ -1,2 +1,8 @@
+DELETE FROM Audit_log;
+DELETE FROM Ownership;
+DELETE FROM Plate_change;
+DELETE FROM Mileage_log;
+DELETE FROM Maintenance;
 DELETE FROM Car;
+DELETE FROM Car_history;
 DELETE FROM People;
\ No newline at end of file
@@ -11,5 +11,179 @@ CREATE TABLE IF NOT EXISTS Car (
     mark varchar(40) NOT NULL CONSTRAINT non_empty_mark CHECK(length(mark)>0),
     model varchar(40) NOT NULL CONSTRAINT non_empty_model CHECK(length(model)>0), 
     year_c integer,
-    id_p integer REFERENCES People(id_p)
+    id_p integer REFERENCES People(id_p),
+    version integer NOT NULL DEFAULT 1,
+    deleted_at timestamptz
 );
+
+CREATE TABLE IF NOT EXISTS Audit_log (
+    id_a bigserial PRIMARY KEY,
+    entity varchar(20) NOT NULL,
+    entity_id varchar(60) NOT NULL,
+    action varchar(20) NOT NULL,
+    actor varchar(100) NOT NULL,
+    request_id varchar(100),
+    before_a jsonb,
+    after_a jsonb,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS Ownership (
+    id_o bigserial PRIMARY KEY,
+    reg_num varchar(12) NOT NULL REFERENCES Car(reg_num) ON UPDATE CASCADE ON DELETE CASCADE,
+    id_p integer NOT NULL REFERENCES People(id_p),
+    valid_from timestamptz NOT NULL DEFAULT now(),
+    valid_to timestamptz,
+    CONSTRAINT valid_period CHECK (valid_to IS NULL OR valid_to >= valid_from)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS ownership_current_idx ON Ownership (reg_num) WHERE valid_to IS NULL;
+
+CREATE TABLE IF NOT EXISTS Car_history (
+    id_h bigserial PRIMARY KEY,
+    reg_num_h varchar(12) NOT NULL,
+    data jsonb NOT NULL,
+    valid_from timestamptz NOT NULL,
+    valid_to timestamptz
+);
+
+CREATE OR REPLACE FUNCTION car_history_track() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        UPDATE Car_history SET valid_to = now()
+        WHERE reg_num_h = OLD.reg_num AND valid_to IS NULL;
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        INSERT INTO Car_history (reg_num_h, data, valid_from)
+        VALUES (NEW.reg_num, to_jsonb(NEW), now());
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER car_history_trigger AFTER INSERT OR UPDATE OR DELETE ON Car
+FOR EACH ROW EXECUTE FUNCTION car_history_track();
+
+CREATE INDEX IF NOT EXISTS car_mark_prefix_idx ON Car (lower(mark) text_pattern_ops);
+CREATE INDEX IF NOT EXISTS car_model_prefix_idx ON Car (lower(model) text_pattern_ops);
+CREATE INDEX IF NOT EXISTS car_mark_model_prefix_idx ON Car (lower(mark), lower(model) text_pattern_ops);
+CREATE INDEX IF NOT EXISTS people_surname_name_prefix_idx ON People (lower(surname_p || ' ' || name_p) text_pattern_ops);
+CREATE INDEX IF NOT EXISTS people_name_surname_prefix_idx ON People (lower(name_p || ' ' || surname_p) text_pattern_ops);
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS car_search_fts_idx ON Car
+USING gin (to_tsvector('simple', reg_num || ' ' || mark || ' ' || model));
+CREATE INDEX IF NOT EXISTS car_search_trgm_idx ON Car
+USING gin ((reg_num || ' ' || mark || ' ' || model) gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS people_search_fts_idx ON People
+USING gin (to_tsvector('simple', name_p || ' ' || surname_p || ' ' || coalesce(patronymic_p, '')));
+CREATE INDEX IF NOT EXISTS people_search_trgm_idx ON People
+USING gin ((name_p || ' ' || surname_p || ' ' || coalesce(patronymic_p, '')) gin_trgm_ops);
+
+CREATE OR REPLACE FUNCTION normalize_plate(p text) RETURNS text AS $$
+    SELECT translate(upper(regexp_replace(p, '\s', '', 'g')), 'АВЕКМНОРСТУХавекмнорстух', 'ABEKMHOPCTYXABEKMHOPCTYX')
+$$ LANGUAGE sql IMMUTABLE STRICT;
+
+CREATE TABLE IF NOT EXISTS Plate_collision (
+    normalized varchar(12) NOT NULL,
+    reg_num varchar(12) NOT NULL,
+    detected_at timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (normalized, reg_num)
+);
+
+ALTER TABLE Car ADD COLUMN IF NOT EXISTS plate_type varchar(20);
+ALTER TABLE Car ADD COLUMN IF NOT EXISTS region_code varchar(3);
+
+ALTER TABLE Car ADD COLUMN IF NOT EXISTS vin varchar(17);
+CREATE UNIQUE INDEX IF NOT EXISTS car_vin_key ON Car (vin);
+
+ALTER TABLE Car ADD COLUMN IF NOT EXISTS id_c bigserial;
+
+ALTER TABLE Ownership DROP CONSTRAINT IF EXISTS ownership_reg_num_fkey;
+ALTER TABLE Car DROP CONSTRAINT IF EXISTS car_pkey;
+ALTER TABLE Car ADD CONSTRAINT car_pkey PRIMARY KEY (id_c);
+ALTER TABLE Car ADD CONSTRAINT car_reg_num_key UNIQUE (reg_num);
+ALTER TABLE Ownership ADD CONSTRAINT ownership_reg_num_fkey
+    FOREIGN KEY (reg_num) REFERENCES Car(reg_num) ON UPDATE CASCADE ON DELETE CASCADE;
+
+CREATE TABLE IF NOT EXISTS Plate_change (
+    id_pc bigserial PRIMARY KEY,
+    id_c bigint NOT NULL REFERENCES Car(id_c) ON DELETE CASCADE,
+    old_reg_num varchar(12) NOT NULL,
+    new_reg_num varchar(12) NOT NULL,
+    changed_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS plate_change_car_idx ON Plate_change (id_c, changed_at);
+CREATE INDEX IF NOT EXISTS plate_change_old_reg_num_idx ON Plate_change (old_reg_num, changed_at);
+
+-- versions of a car are linked by id_c, the plate may differ between them
+ALTER TABLE Car_history ADD COLUMN IF NOT EXISTS id_c_h bigint;
+UPDATE Car_history AS h SET id_c_h = c.id_c FROM Car AS c WHERE h.reg_num_h = c.reg_num;
+CREATE INDEX IF NOT EXISTS car_history_car_idx ON Car_history (id_c_h, valid_from);
+
+CREATE OR REPLACE FUNCTION car_history_track() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        UPDATE Car_history SET valid_to = now()
+        WHERE id_c_h = OLD.id_c AND valid_to IS NULL;
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        INSERT INTO Car_history (reg_num_h, id_c_h, data, valid_from)
+        VALUES (NEW.reg_num, NEW.id_c, to_jsonb(NEW), now());
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE Car ADD COLUMN IF NOT EXISTS color varchar(30);
+ALTER TABLE Car ADD COLUMN IF NOT EXISTS body_type varchar(20) CONSTRAINT known_body_type
+    CHECK (body_type IN ('sedan', 'hatchback', 'wagon', 'coupe', 'convertible', 'suv', 'crossover', 'minivan', 'pickup', 'van', 'bus', 'truck', 'motorcycle'));
+ALTER TABLE Car ADD COLUMN IF NOT EXISTS fuel varchar(20) CONSTRAINT known_fuel
+    CHECK (fuel IN ('petrol', 'diesel', 'lpg', 'cng', 'hybrid', 'electric'));
+ALTER TABLE Car ADD COLUMN IF NOT EXISTS engine_volume integer CONSTRAINT positive_engine_volume CHECK (engine_volume > 0);
+ALTER TABLE Car ADD COLUMN IF NOT EXISTS transmission varchar(20) CONSTRAINT known_transmission
+    CHECK (transmission IN ('manual', 'automatic', 'robot', 'cvt'));
+ALTER TABLE Car ADD COLUMN IF NOT EXISTS mileage integer CONSTRAINT non_negative_mileage CHECK (mileage >= 0);
+
+CREATE INDEX IF NOT EXISTS car_mileage_idx ON Car (mileage);
+
+-- odometer readings of a car; a reading out of order by date is only kept
+-- when the client overrides the check, and then it marks the car
+CREATE TABLE IF NOT EXISTS Mileage_log (
+    id_ml bigserial PRIMARY KEY,
+    id_c bigint NOT NULL REFERENCES Car(id_c) ON DELETE CASCADE,
+    mileage integer NOT NULL CONSTRAINT non_negative_reading CHECK (mileage >= 0),
+    read_on date NOT NULL,
+    rollback boolean NOT NULL DEFAULT false,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS mileage_log_car_idx ON Mileage_log (id_c, read_on);
+
+ALTER TABLE Car ADD COLUMN IF NOT EXISTS suspicious_rollback boolean NOT NULL DEFAULT false;
+
+-- service and maintenance events of a car
+CREATE TABLE IF NOT EXISTS Maintenance (
+    id_m bigserial PRIMARY KEY,
+    id_c bigint NOT NULL REFERENCES Car(id_c) ON DELETE CASCADE,
+    done_on date NOT NULL,
+    mileage integer CONSTRAINT non_negative_service_mileage CHECK (mileage >= 0),
+    work_type varchar(30) NOT NULL CONSTRAINT known_work_type
+        CHECK (work_type IN ('service', 'oil_change', 'inspection', 'repair', 'tires', 'brakes', 'battery', 'bodywork', 'other')),
+    -- cost is kept in kopecks
+    cost bigint CONSTRAINT non_negative_cost CHECK (cost >= 0),
+    notes text,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS maintenance_car_idx ON Maintenance (id_c, done_on);
+
+-- transitions between statuses are checked by the service
+ALTER TABLE Car ADD COLUMN IF NOT EXISTS status varchar(20) NOT NULL DEFAULT 'active' CONSTRAINT known_status
+    CHECK (status IN ('active', 'sold', 'stolen', 'scrapped', 'exported'));
+ALTER TABLE Car ADD COLUMN IF NOT EXISTS status_reason text;
+
+CREATE INDEX IF NOT EXISTS car_status_idx ON Car (status);